@@ -1,27 +1,132 @@
 package api
 
 import (
+	"main/app/api/gql"
 	v1 "main/app/api/v1"
+	"main/app/pkg/apierror"
+	. "main/app/pkg/configs"
+	"main/app/pkg/db"
+	"main/app/pkg/response"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/idempotency"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
 )
 
+// idempotent replays the stored response of a request whose Idempotency-Key
+// was already used on the same scope and path, so that a key reused on
+// another counter is not replayed. Failed requests are not stored.
+func idempotent(scope string) fiber.Handler {
+	config := idempotency.Config{
+		Lifetime:  Configs.Duration("idempotency.lifetime"),
+		KeyHeader: "Idempotency-Key",
+		KeyHeaderValidate: func(key string) error {
+			if len(key) == 0 || len(key) > 255 {
+				return apierror.BadRequest(apierror.CodeInvalidIdempotencyKey, "'Idempotency-Key' must be between 1 and 255 characters")
+			}
+
+			return nil
+		},
+		KeepResponseHeaders: []string{fiber.HeaderContentType},
+		// shared by the requests, the storage is not
+		Lock: idempotency.NewMemoryLock(),
+	}
+
+	return func(c *fiber.Ctx) error {
+		config := config
+		config.Storage = db.Q.Idempotency.WithScope(scope + ":" + c.Path())
+
+		return idempotency.New(config)(c)
+	}
+}
+
+// cacheControl sets the Cache-Control policy configured for the group on
+// successful responses.
+func cacheControl(group string) fiber.Handler {
+	policy := Configs.String("cache." + group)
+
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		if policy != "" && c.Response().StatusCode() < fiber.StatusBadRequest {
+			c.Set(fiber.HeaderCacheControl, policy)
+		}
+
+		return nil
+	}
+}
+
+// deprecated announces the sunset of a version and its successor.
+func deprecated(successor string) fiber.Handler {
+	sunset := Configs.String("api.v1Sunset")
+
+	return func(c *fiber.Ctx) error {
+		c.Set("Deprecation", "true")
+		if sunset != "" {
+			c.Set("Sunset", sunset)
+		}
+		c.Set(fiber.HeaderLink, "<"+successor+">; rel=\"successor-version\"")
+
+		return c.Next()
+	}
+}
+
 func SetRoutes(a *fiber.App) {
-	route := a.Group("/api/v1")
+	setDocsAssetsRoute(a)
+	setDocsRoutes(a, "/api/v1")
+	setDocsRoutes(a, "/api/v2")
+
+	setVersionRoutes(a.Group("/api/v1", deprecated("/api/v2")))
+	setVersionRoutes(a.Group("/api/v2", response.Enveloped))
+
+	a.Get("/t/:token", limiter.New(limiter.Config{
+		Max:          Configs.Int("triggers.maxPerMinute"),
+		Expiration:   time.Minute,
+		LimitReached: v1.TriggerLimitReached,
+	}), v1.UseTrigger)
 
-	route.Get("/counters", v1.GetCounters)
-	route.Post("/counters", v1.CreateCounter)
-	route.Get("/counters/:id", v1.GetCounter)
+	a.Get("/graphql", gql.Handler)
+	a.Post("/graphql", gql.Handler)
+}
+
+// setVersionRoutes registers the routes shared by every version, the
+// versions only differ by their middlewares.
+func setVersionRoutes(route fiber.Router) {
+	counters := cacheControl("counters")
+	data := cacheControl("data")
+	stats := cacheControl("stats")
+
+	route.Get("/counters", counters, v1.GetCounters)
+	route.Post("/counters", idempotent("counters"), v1.CreateCounter)
+	route.Get("/counters/:id", counters, v1.GetCounter)
 	route.Patch("/counters/:id", v1.EditCounter)
 	route.Delete("/counters/:id", v1.DeleteCounter)
-	route.Get("/counters/:id/data", v1.GetCounterData)
-	route.Get("/counters/:id/dataByMonth", v1.GetCounterDataByMonth)
-	route.Get("/counters/:id/sum", v1.GetCounterSum)
-	route.Get("/counters/:id/avg", v1.GetCounterAvg)
-	route.Get("/counters/:id/stats", v1.GetCounterStats)
-
-	route.Post("/datas", v1.CreateData)
-	route.Get("/datas", v1.GetDatas)
-	route.Get("/datas/:id", v1.GetData)
+	route.Post("/counters/:id/increment", idempotent("increment"), v1.IncrementCounter)
+	route.Post("/counters/:id/undo", idempotent("undo"), v1.UndoCounter)
+	route.Get("/counters/:id/triggers", v1.GetTriggers)
+	route.Post("/counters/:id/triggers", idempotent("triggers"), v1.CreateTrigger)
+	route.Delete("/counters/:id/triggers/:triggerId", v1.DeleteTrigger)
+	route.Get("/counters/:id/export", v1.ExportCounter)
+	route.Get("/counters/:id/data", data, v1.GetCounterData)
+	route.Get("/counters/:id/dataByMonth", stats, v1.GetCounterDataByMonth)
+	route.Get("/counters/:id/sum", stats, v1.GetCounterSum)
+	route.Get("/counters/:id/avg", stats, v1.GetCounterAvg)
+	route.Get("/counters/:id/stats", stats, v1.GetCounterStats)
+	route.Get("/counters/:id/series", stats, v1.GetCounterSeries)
+	route.Get("/counters/:id/calendar", stats, v1.GetCounterCalendar)
+	route.Get("/counters/:id/trend", stats, v1.GetCounterTrend)
+	route.Get("/counters/:id/forecast", stats, v1.GetCounterForecast)
+
+	route.Get("/dashboard", stats, v1.GetDashboard)
+	route.Get("/feed", data, v1.GetFeed)
+	route.Get("/compare", stats, v1.GetCompare)
+	route.Get("/export", v1.ExportCounters)
+
+	route.Post("/datas", idempotent("datas"), v1.CreateData)
+	route.Get("/datas", data, v1.GetDatas)
+	route.Get("/datas/:id", data, v1.GetData)
 	route.Delete("/datas/:id", v1.DeleteData)
 }
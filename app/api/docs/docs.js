@@ -0,0 +1,5 @@
+// Kept out of docs.html so that the page works with a script-src 'self' CSP.
+window.onload = () => {
+    const root = document.getElementById("swagger-ui");
+    window.ui = SwaggerUIBundle({ url: root.dataset.url, dom_id: "#swagger-ui" });
+};
@@ -0,0 +1,160 @@
+package gql
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"main/app/pkg/apierror"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// keepAlive is the interval of the comments sent on idle subscriptions, it
+// also detects the clients that left.
+const keepAlive = 15 * time.Second
+
+type request struct {
+	Query         string         `json:"query"         query:"query"`
+	OperationName string         `json:"operationName" query:"operationName"`
+	Variables     map[string]any `json:"variables"     query:"-"`
+}
+
+func parseRequest(c *fiber.Ctx) (request, error) {
+	var req request
+
+	if c.Method() == fiber.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return req, apierror.InvalidParameter("'variables': expected a JSON object")
+			}
+		}
+	} else if err := c.BodyParser(&req); err != nil {
+		return req, apierror.BadRequest(apierror.CodeInvalidBody, "cannot parse the request body")
+	}
+
+	if strings.TrimSpace(req.Query) == "" {
+		return req, apierror.InvalidParameter("'query' may not be empty")
+	}
+
+	return req, nil
+}
+
+// isSubscription tells whether the requested operation is a subscription,
+// the invalid documents are reported by the execution.
+func isSubscription(req request) bool {
+	document, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return false
+	}
+
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if req.OperationName == "" || (operation.Name != nil && operation.Name.Value == req.OperationName) {
+			return operation.Operation == ast.OperationTypeSubscription
+		}
+	}
+
+	return false
+}
+
+// publicErrors replaces the errors of the resolvers by the message of their
+// API error, so that the causes of server errors are only logged.
+func publicErrors(result *graphql.Result) *graphql.Result {
+	for i, formatted := range result.Errors {
+		located, ok := formatted.OriginalError().(*gqlerrors.Error)
+		if !ok || located.OriginalError == nil {
+			continue
+		}
+
+		apiError := apierror.From(located.OriginalError)
+		if apiError.Status >= fiber.StatusInternalServerError {
+			log.Printf("graphql %v: %v", located.Path, located.OriginalError)
+		}
+		result.Errors[i].Message = apiError.Msg
+		result.Errors[i].Extensions = map[string]any{"code": apiError.Code}
+	}
+
+	return result
+}
+
+// Handler executes the queries, the subscriptions are streamed as
+// Server-Sent Events, one "next" event per result then a "complete" event.
+func Handler(c *fiber.Ctx) error {
+	req, err := parseRequest(c)
+	if err != nil {
+		return err
+	}
+
+	params := graphql.Params{
+		Schema:         schema,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+	}
+
+	if !isSubscription(req) {
+		params.Context = withLoaders(c.UserContext(), newLoaders(true))
+		return c.JSON(publicErrors(graphql.Do(params)))
+	}
+
+	if !strings.Contains(c.Get(fiber.HeaderAccept), "text/event-stream") {
+		return apierror.New(fiber.StatusNotAcceptable, apierror.CodeBadRequest, "subscriptions require 'Accept: text/event-stream'")
+	}
+
+	ctx, cancel := context.WithCancel(withLoaders(context.Background(), newLoaders(false)))
+	params.Context = ctx
+	results := graphql.Subscribe(params)
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer func() {
+			cancel()
+			// unblocks the subscription until it sees the cancellation
+			for range results {
+			}
+		}()
+
+		ticker := time.NewTicker(keepAlive)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case result, ok := <-results:
+				if !ok {
+					fmt.Fprint(w, "event: complete\ndata:\n\n")
+					w.Flush()
+					return
+				}
+
+				payload, err := json.Marshal(publicErrors(result))
+				if err != nil {
+					return
+				}
+				fmt.Fprintf(w, "event: next\ndata: %s\n\n", payload)
+			case <-ticker.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
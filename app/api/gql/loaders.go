@@ -0,0 +1,182 @@
+package gql
+
+import (
+	"context"
+	"fmt"
+	"main/app/models"
+	"main/app/pkg/db"
+	"main/app/queries"
+	"time"
+
+	"github.com/graph-gophers/dataloader/v7"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxBatch bounds the size of a batched query.
+const maxBatch = 100
+
+type recentKey struct {
+	Counter primitive.ObjectID
+	Limit   int
+}
+
+type seriesKey struct {
+	Counter  models.Counter
+	Interval string
+	Options  queries.CounterOptions
+}
+
+// batch identifies the keys queried together, the options are compared by
+// value since every resolver parses its own.
+func (key seriesKey) batch() string {
+	opts := key.Options
+	batch := fmt.Sprintf("%s|%t|%s", key.Interval, opts.Global, opts.Location)
+	for _, t := range []*time.Time{opts.From, opts.To} {
+		batch += "|"
+		if t != nil {
+			batch += t.UTC().Format(time.RFC3339Nano)
+		}
+	}
+
+	return batch
+}
+
+// loaders batch the lookups of the resolvers of a request, so that a list
+// of counters costs one query per field instead of one per counter.
+type loaders struct {
+	counters *dataloader.Loader[primitive.ObjectID, models.Counter]
+	stats    *dataloader.Loader[queries.CounterQuery, queries.CounterStats]
+	recent   *dataloader.Loader[recentKey, []models.Data]
+	series   *dataloader.Loader[seriesKey, []queries.SeriesBucket]
+}
+
+type loadersKey struct{}
+
+// newLoaders creates the loaders of a request, the subscriptions do not cache
+// so that every event sees the current counters.
+func newLoaders(cache bool) *loaders {
+	return &loaders{
+		counters: dataloader.NewBatchedLoader(loadCounters, loaderOptions[primitive.ObjectID, models.Counter](cache)...),
+		stats:    dataloader.NewBatchedLoader(loadStats, loaderOptions[queries.CounterQuery, queries.CounterStats](cache)...),
+		recent:   dataloader.NewBatchedLoader(loadRecent, loaderOptions[recentKey, []models.Data](cache)...),
+		series:   dataloader.NewBatchedLoader(loadSeries, loaderOptions[seriesKey, []queries.SeriesBucket](cache)...),
+	}
+}
+
+func loaderOptions[K comparable, V any](cache bool) []dataloader.Option[K, V] {
+	options := []dataloader.Option[K, V]{dataloader.WithBatchCapacity[K, V](maxBatch)}
+	if !cache {
+		options = append(options, dataloader.WithCache[K, V](&dataloader.NoCache[K, V]{}))
+	}
+
+	return options
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func failed[V any](size int, err error) []*dataloader.Result[V] {
+	results := make([]*dataloader.Result[V], size)
+	for i := range results {
+		results[i] = &dataloader.Result[V]{Error: err}
+	}
+
+	return results
+}
+
+// loadCounters returns a zero counter for the ids that do not exist.
+func loadCounters(_ context.Context, ids []primitive.ObjectID) []*dataloader.Result[models.Counter] {
+	counters, err := db.Q.GetCountersByIDs(ids)
+	if err != nil {
+		return failed[models.Counter](len(ids), err)
+	}
+
+	byID := map[primitive.ObjectID]models.Counter{}
+	for _, counter := range counters {
+		byID[counter.ID] = counter
+	}
+
+	results := make([]*dataloader.Result[models.Counter], len(ids))
+	for i, id := range ids {
+		results[i] = &dataloader.Result[models.Counter]{Data: byID[id]}
+	}
+
+	return results
+}
+
+func loadStats(_ context.Context, keys []queries.CounterQuery) []*dataloader.Result[queries.CounterStats] {
+	stats, err := db.Q.GetCountersStats(keys)
+	if err != nil {
+		return failed[queries.CounterStats](len(keys), err)
+	}
+
+	results := make([]*dataloader.Result[queries.CounterStats], len(keys))
+	for i := range keys {
+		results[i] = &dataloader.Result[queries.CounterStats]{Data: stats[i]}
+	}
+
+	return results
+}
+
+// loadRecent queries the counters once per distinct limit.
+func loadRecent(_ context.Context, keys []recentKey) []*dataloader.Result[[]models.Data] {
+	byLimit := map[int][]primitive.ObjectID{}
+	for _, key := range keys {
+		byLimit[key.Limit] = append(byLimit[key.Limit], key.Counter)
+	}
+
+	datas := map[recentKey][]models.Data{}
+	for limit, ids := range byLimit {
+		recent, err := db.Q.GetRecentDatas(ids, limit)
+		if err != nil {
+			return failed[[]models.Data](len(keys), err)
+		}
+		for id, data := range recent {
+			datas[recentKey{id, limit}] = data
+		}
+	}
+
+	results := make([]*dataloader.Result[[]models.Data], len(keys))
+	for i, key := range keys {
+		data := datas[key]
+		if data == nil {
+			data = []models.Data{}
+		}
+		results[i] = &dataloader.Result[[]models.Data]{Data: data}
+	}
+
+	return results
+}
+
+// loadSeries queries the counters once per interval and options.
+func loadSeries(_ context.Context, keys []seriesKey) []*dataloader.Result[[]queries.SeriesBucket] {
+	batches := map[string][]int{}
+	for i, key := range keys {
+		batches[key.batch()] = append(batches[key.batch()], i)
+	}
+
+	results := make([]*dataloader.Result[[]queries.SeriesBucket], len(keys))
+	for _, indexes := range batches {
+		first := keys[indexes[0]]
+		counters := make([]models.Counter, len(indexes))
+		for i, index := range indexes {
+			counters[i] = keys[index].Counter
+		}
+
+		series, err := db.Q.GetCountersSeries(counters, first.Interval, first.Options)
+		for i, index := range indexes {
+			if err != nil {
+				results[index] = &dataloader.Result[[]queries.SeriesBucket]{Error: err}
+				continue
+			}
+			results[index] = &dataloader.Result[[]queries.SeriesBucket]{Data: series[i]}
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,389 @@
+package gql
+
+import (
+	"errors"
+	"fmt"
+	"main/app/models"
+	"main/app/pkg/apierror"
+	"main/app/pkg/db"
+	"main/app/pkg/events"
+	"main/app/pkg/utils"
+	"main/app/queries"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// the arguments of the statistics of a counter, like the query parameters of
+// the v1 routes
+var counterOptionsArgs = graphql.FieldConfigArgument{
+	"global": &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false, Description: "ignore the soft reset of the counter"},
+	"from":   &graphql.ArgumentConfig{Type: graphql.DateTime, Description: "range start"},
+	"to":     &graphql.ArgumentConfig{Type: graphql.DateTime, Description: "range end, excluded"},
+	"tz":     &graphql.ArgumentConfig{Type: graphql.String, Description: "IANA timezone, the one of the counter or UTC by default"},
+}
+
+func withCounterOptionsArgs(args graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	for name, arg := range counterOptionsArgs {
+		args[name] = arg
+	}
+
+	return args
+}
+
+func counterOptions(counter models.Counter, args map[string]any) (queries.CounterOptions, error) {
+	opts := queries.CounterOptions{}
+	opts.Global, _ = args["global"].(bool)
+
+	name, _ := args["tz"].(string)
+	if name == "" {
+		name = counter.Timezone
+	}
+	loc, err := utils.LoadLocation(name)
+	if err != nil {
+		return opts, apierror.InvalidParameter(fmt.Sprintf("'tz': invalid timezone '%s'", name))
+	}
+	opts.Location = loc
+
+	if from, ok := args["from"].(time.Time); ok {
+		opts.From = &from
+	}
+	if to, ok := args["to"].(time.Time); ok {
+		opts.To = &to
+	}
+	if opts.From != nil && opts.To != nil && !opts.From.Before(*opts.To) {
+		return opts, apierror.InvalidParameter("'from' must be before 'to'")
+	}
+
+	return opts, nil
+}
+
+func objectIDs(args map[string]any, key string) ([]primitive.ObjectID, error) {
+	values, _ := args[key].([]any)
+
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		id, err := primitive.ObjectIDFromHex(value.(string))
+		if err != nil {
+			return nil, apierror.InvalidParameter(fmt.Sprintf("'%s': invalid id '%s'", key, value))
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func resolveTime(get func(source any) primitive.DateTime) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		return get(p.Source).Time(), nil
+	}
+}
+
+var statsType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Stats",
+	Fields: graphql.Fields{
+		"total": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"avg":   &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+		"days":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+var seriesBucketType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "SeriesBucket",
+	Fields: graphql.Fields{
+		"date":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"sum":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"count": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"avg":   &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+		"min":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"max":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+var entryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Entry",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.ID),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(models.Data).ID.Hex(), nil
+			},
+		},
+		"number": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"tags": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				if tags := p.Source.(models.Data).Tags; tags != nil {
+					return tags, nil
+				}
+				return []string{}, nil
+			},
+		},
+		"createdAt": &graphql.Field{
+			Type:    graphql.NewNonNull(graphql.DateTime),
+			Resolve: resolveTime(func(source any) primitive.DateTime { return source.(models.Data).CreatedAt }),
+		},
+		"updatedAt": &graphql.Field{
+			Type:    graphql.NewNonNull(graphql.DateTime),
+			Resolve: resolveTime(func(source any) primitive.DateTime { return source.(models.Data).UpdatedAt }),
+		},
+	},
+})
+
+var counterType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Counter",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.ID),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(models.Counter).ID.Hex(), nil
+			},
+		},
+		"name": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"slug": &graphql.Field{Type: graphql.String},
+		"softReset": &graphql.Field{
+			Type: graphql.DateTime,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				if softReset := p.Source.(models.Counter).SoftReset; softReset != nil {
+					return softReset.Time(), nil
+				}
+				return nil, nil
+			},
+		},
+		"timezone": &graphql.Field{Type: graphql.String},
+		"color":    &graphql.Field{Type: graphql.String},
+		"min":      &graphql.Field{Type: graphql.Int},
+		"max":      &graphql.Field{Type: graphql.Int},
+		"createdAt": &graphql.Field{
+			Type:    graphql.NewNonNull(graphql.DateTime),
+			Resolve: resolveTime(func(source any) primitive.DateTime { return source.(models.Counter).CreatedAt }),
+		},
+		"updatedAt": &graphql.Field{
+			Type:    graphql.NewNonNull(graphql.DateTime),
+			Resolve: resolveTime(func(source any) primitive.DateTime { return source.(models.Counter).UpdatedAt }),
+		},
+		"stats": &graphql.Field{
+			Type: graphql.NewNonNull(statsType),
+			Args: withCounterOptionsArgs(graphql.FieldConfigArgument{}),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				counter := p.Source.(models.Counter)
+				opts, err := counterOptions(counter, p.Args)
+				if err != nil {
+					return nil, err
+				}
+
+				thunk := loadersFrom(p.Context).stats.Load(p.Context, queries.CounterQuery{Counter: counter, Options: opts})
+				return func() (any, error) { return thunk() }, nil
+			},
+		},
+		"series": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(seriesBucketType))),
+			Args: withCounterOptionsArgs(graphql.FieldConfigArgument{
+				"interval": &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: "day", Description: "bucket size"},
+			}),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				counter := p.Source.(models.Counter)
+				opts, err := counterOptions(counter, p.Args)
+				if err != nil {
+					return nil, err
+				}
+
+				interval := p.Args["interval"].(string)
+				if !slices.Contains(utils.Intervals, interval) {
+					return nil, apierror.InvalidParameter(fmt.Sprintf("'interval': invalid interval '%s', expected one of %s", interval, strings.Join(utils.Intervals, ", ")))
+				}
+
+				thunk := loadersFrom(p.Context).series.Load(p.Context, seriesKey{Counter: counter, Interval: interval, Options: opts})
+				return func() (any, error) {
+					series, err := thunk()
+					if errors.Is(err, queries.ErrTooManyBuckets) {
+						return nil, apierror.InvalidParameter(err.Error())
+					}
+					return series, err
+				}, nil
+			},
+		},
+		"recentEntries": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(entryType))),
+			Args: graphql.FieldConfigArgument{
+				"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 10},
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				limit := p.Args["limit"].(int)
+				if limit < 1 || limit > 100 {
+					return nil, apierror.InvalidParameter("'limit': expected a number between 1 and 100")
+				}
+
+				key := recentKey{Counter: p.Source.(models.Counter).ID, Limit: limit}
+				thunk := loadersFrom(p.Context).recent.Load(p.Context, key)
+				return func() (any, error) { return thunk() }, nil
+			},
+		},
+	},
+})
+
+// entryCounter is added once both types exist, the counter of an entry is
+// loaded in batch and null once deleted.
+var entryCounter = &graphql.Field{
+	Type: counterType,
+	Resolve: func(p graphql.ResolveParams) (any, error) {
+		thunk := loadersFrom(p.Context).counters.Load(p.Context, p.Source.(models.Data).Counter)
+		return func() (any, error) {
+			counter, err := thunk()
+			if err != nil || counter.ID.IsZero() {
+				return nil, err
+			}
+			return counter, nil
+		}, nil
+	},
+}
+
+var queryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Query",
+	Fields: graphql.Fields{
+		"counters": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(counterType))),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				counters, err := db.Q.GetCounters()
+				if counters == nil {
+					counters = []models.Counter{}
+				}
+				return counters, err
+			},
+		},
+		"counter": &graphql.Field{
+			Type: counterType,
+			Args: graphql.FieldConfigArgument{
+				"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID), Description: "id or slug"},
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				counter, err := db.Q.GetCounter(p.Args["id"].(string))
+				if errors.Is(err, mongo.ErrNoDocuments) {
+					return nil, nil
+				}
+				return counter, err
+			},
+		},
+		"entries": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(entryType))),
+			Args: graphql.FieldConfigArgument{
+				"counters": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.ID))},
+				"from":     &graphql.ArgumentConfig{Type: graphql.DateTime, Description: "created at or after"},
+				"to":       &graphql.ArgumentConfig{Type: graphql.DateTime, Description: "created before"},
+				"tags":     &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+				"limit":    &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 50},
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				limit := p.Args["limit"].(int)
+				if limit < 1 || limit > 1000 {
+					return nil, apierror.InvalidParameter("'limit': expected a number between 1 and 1000")
+				}
+
+				opts := queries.ListOptions{Limit: int64(limit), Ordering: "-createdAt"}
+				counters, err := objectIDs(p.Args, "counters")
+				if err != nil {
+					return nil, err
+				}
+				opts.Counters = counters
+				if from, ok := p.Args["from"].(time.Time); ok {
+					opts.From = &from
+				}
+				if to, ok := p.Args["to"].(time.Time); ok {
+					opts.To = &to
+				}
+				tags, _ := p.Args["tags"].([]any)
+				for _, tag := range tags {
+					opts.Tags = append(opts.Tags, tag.(string))
+				}
+
+				datas, err := db.Q.GetDatas(opts)
+				if datas == nil {
+					datas = []models.Data{}
+				}
+				return datas, err
+			},
+		},
+	},
+})
+
+// subscribeEntries streams the created entries of the counters of the
+// arguments, or the ones deleted by an undo.
+func subscribeEntries(deleted bool) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		counters, err := objectIDs(p.Args, "counters")
+		if err != nil {
+			return nil, err
+		}
+
+		entries, cancel := events.Entries.Subscribe(counters)
+		source := make(chan any)
+		go func() {
+			defer close(source)
+			defer cancel()
+
+			for {
+				select {
+				case <-p.Context.Done():
+					return
+				case event := <-entries:
+					if event.Deleted != deleted {
+						continue
+					}
+					select {
+					case source <- event.Entry:
+					case <-p.Context.Done():
+						return
+					}
+				}
+			}
+		}()
+
+		return source, nil
+	}
+}
+
+var subscriptionArgs = graphql.FieldConfigArgument{
+	"counters": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.ID))},
+}
+
+var subscriptionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Subscription",
+	Fields: graphql.Fields{
+		"entryCreated": &graphql.Field{
+			Type:        graphql.NewNonNull(entryType),
+			Description: "entries created on this server, of the counters or of all of them",
+			Args:        subscriptionArgs,
+			Subscribe:   subscribeEntries(false),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source, nil
+			},
+		},
+		"entryDeleted": &graphql.Field{
+			Type:        graphql.NewNonNull(entryType),
+			Description: "entries deleted by an undo on this server, of the counters or of all of them",
+			Args:        subscriptionArgs,
+			Subscribe:   subscribeEntries(true),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source, nil
+			},
+		},
+	},
+})
+
+var schema = func() graphql.Schema {
+	entryType.AddFieldConfig("counter", entryCounter)
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query:        queryType,
+		Subscription: subscriptionType,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return schema
+}()
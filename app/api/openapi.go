@@ -0,0 +1,765 @@
+package api
+
+import (
+	"embed"
+	"fmt"
+	"main/app/pkg/apierror"
+	"main/app/pkg/utils"
+	"main/app/queries"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type M = map[string]any
+
+//go:embed docs/docs.html
+var docsPage string
+
+// docsFiles are the scripts and styles of the docs page, swagger-ui is
+// embedded so that the page works offline and without third party origins.
+//
+//go:embed docs/docs.js docs/swagger-ui/*.js docs/swagger-ui/*.css docs/swagger-ui/*.png
+var docsFiles embed.FS
+
+// docsAssets is the prefix of the docs files, shared by every version.
+const docsAssets = "/api/docs/assets"
+
+// undocumented lists the routes the spec leaves out, the files of the docs
+// page are not part of the API.
+var undocumented = map[string]bool{
+	fiber.MethodGet + " " + docsAssets + "/*": true,
+}
+
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+func ref(name string) M {
+	return M{"$ref": "#/components/schemas/" + name}
+}
+
+func paramRef(name string) M {
+	return M{"$ref": "#/components/parameters/" + name}
+}
+
+func arrayOf(items M) M {
+	return M{"type": "array", "items": items}
+}
+
+func nullable(typ string) M {
+	return M{"type": []string{typ, "null"}}
+}
+
+func object(required []string, properties M) M {
+	schema := M{"type": "object", "properties": properties}
+	if len(required) != 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+func query(name string, description string, schema M) M {
+	return M{"name": name, "in": "query", "description": description, "schema": schema}
+}
+
+func header(name string, description string) M {
+	return M{"name": name, "in": "header", "description": description, "schema": M{"type": "string"}}
+}
+
+func jsonBody(schema M) M {
+	return M{"required": true, "content": M{fiber.MIMEApplicationJSON: M{"schema": schema}}}
+}
+
+func responses(status string, description string, schema M) M {
+	response := M{"description": description}
+	if schema != nil {
+		response["content"] = M{fiber.MIMEApplicationJSON: M{"schema": schema}}
+	}
+
+	return M{
+		status:    response,
+		"default": M{"$ref": "#/components/responses/Error"},
+	}
+}
+
+// exportResponses are the files of an export, streamed in the requested format.
+func exportResponses() M {
+	row := ref("ExportRow")
+	return M{
+		"200": M{
+			"description": "Exported data, ordered by creation",
+			"content": M{
+				"text/csv":                M{"schema": M{"type": "string"}},
+				fiber.MIMEApplicationJSON: M{"schema": arrayOf(row)},
+				"application/x-ndjson":    M{"schema": row},
+			},
+		},
+		"default": M{"$ref": "#/components/responses/Error"},
+	}
+}
+
+func operation(tag string, summary string, parameters []M, body M, responses M) M {
+	op := M{"tags": []string{tag}, "summary": summary, "responses": responses}
+	if len(parameters) != 0 {
+		op["parameters"] = parameters
+	}
+	if body != nil {
+		op["requestBody"] = body
+	}
+
+	return op
+}
+
+var counterOptions = []M{
+	paramRef("id"),
+	paramRef("global"),
+	paramRef("range"),
+	paramRef("from"),
+	paramRef("to"),
+	paramRef("tz"),
+}
+
+func withCounterOptions(parameters ...M) []M {
+	return append(slices.Clone(counterOptions), parameters...)
+}
+
+// spec lists the documented operations by method and fiber path, relative to
+// the version prefix.
+var spec = map[string]map[string]M{
+	"/openapi.json": {
+		fiber.MethodGet: operation("docs", "OpenAPI document of the API", nil, nil,
+			responses("200", "OpenAPI 3.1 document", M{"type": "object"})),
+	},
+	"/docs": {
+		fiber.MethodGet: operation("docs", "Interactive documentation", nil, nil,
+			M{"200": M{"description": "HTML page", "content": M{fiber.MIMETextHTML: M{}}}}),
+	},
+	"/counters": {
+		fiber.MethodGet: operation("counters", "List the counters",
+			[]M{
+				query("q", "fuzzy search of the names, the best matches first", M{"type": "string"}),
+				paramRef("If-None-Match"),
+			}, nil,
+			responses("200", "Counters", arrayOf(ref("Counter")))),
+		fiber.MethodPost: operation("counters", "Create a counter", []M{paramRef("Idempotency-Key")}, jsonBody(ref("Counter")),
+			responses("200", "Created counter", ref("Counter"))),
+	},
+	"/counters/:id": {
+		fiber.MethodGet: operation("counters", "Get a counter", []M{paramRef("id"), paramRef("If-None-Match")}, nil,
+			responses("200", "Counter, its ETag is used by If-Match", ref("Counter"))),
+		fiber.MethodPatch: operation("counters", "Edit a counter",
+			[]M{paramRef("id"), paramRef("If-Match")},
+			jsonBody(object(nil, M{
+				"name":      M{"type": "string"},
+				"slug":      M{"type": []string{"string", "null"}, "description": "empty or null to generate it from the name again"},
+				"softReset": nullable("string"),
+				"timezone":  M{"type": "string"},
+				"color":     M{"type": "string"},
+				"min":       nullable("integer"),
+				"max":       nullable("integer"),
+			})),
+			responses("200", "Edited counter", ref("Counter"))),
+		fiber.MethodDelete: operation("counters", "Delete a counter and its data", []M{paramRef("id"), paramRef("If-Match")}, nil,
+			responses("204", "Deleted", nil)),
+	},
+	"/counters/:id/increment": {
+		fiber.MethodPost: operation("counters", "Add an entry to a counter, the parameters may also be sent as a JSON body",
+			withCounterOptions(
+				query("by", "number of the entry", M{"type": "integer", "default": 1}),
+				query("at", "creation date of the entry, now by default, may not be in the future", M{"type": "string"}),
+				query("tags", "comma separated tags", M{"type": "string"}),
+				paramRef("Idempotency-Key"),
+			),
+			M{"content": M{fiber.MIMEApplicationJSON: M{"schema": object(nil, M{
+				"by":   M{"type": "integer", "default": 1},
+				"at":   M{"type": "string", "format": "date-time", "description": "may not be in the future"},
+				"tags": arrayOf(M{"type": "string"}),
+			})}}},
+			responses("200", "Created entry and statistics", ref("Increment"))),
+	},
+	"/counters/:id/undo": {
+		fiber.MethodPost: operation("counters", "Delete the last entry written within the undo window",
+			withCounterOptions(paramRef("Idempotency-Key")), nil,
+			responses("200", "Deleted entry and statistics", ref("Increment"))),
+	},
+	"/counters/:id/triggers": {
+		fiber.MethodGet: operation("triggers", "List the trigger URLs of a counter", []M{paramRef("id")}, nil,
+			responses("200", "Triggers, without their token", arrayOf(ref("Trigger")))),
+		fiber.MethodPost: operation("triggers", "Create a trigger URL, GET /t/{token} adds its entry",
+			[]M{paramRef("id"), paramRef("Idempotency-Key")}, jsonBody(ref("Trigger")),
+			responses("200", "Created trigger, its token is only sent once", M{"allOf": []M{
+				ref("Trigger"),
+				object([]string{"token", "url"}, M{
+					"token": M{"type": "string"},
+					"url":   M{"type": "string"},
+				}),
+			}})),
+	},
+	"/counters/:id/triggers/:triggerId": {
+		fiber.MethodDelete: operation("triggers", "Revoke a trigger URL",
+			[]M{paramRef("id"), {"name": "triggerId", "in": "path", "required": true, "schema": M{"type": "string"}}}, nil,
+			responses("204", "Revoked", nil)),
+	},
+	"/counters/:id/export": {
+		fiber.MethodGet: operation("export", "Export the data of a counter",
+			withCounterOptions(paramRef("format")), nil, exportResponses()),
+	},
+	"/counters/:id/data": {
+		fiber.MethodGet: operation("statistics", "Data of a counter", withCounterOptions(paramRef("If-None-Match")), nil,
+			responses("200", "Data ordered by creation", arrayOf(ref("Data")))),
+	},
+	"/counters/:id/dataByMonth": {
+		fiber.MethodGet: operation("statistics", "Monthly totals of a counter", counterOptions, nil,
+			responses("200", "Totals by month", arrayOf(object(nil, M{
+				"date":  M{"type": "string", "description": "month as MM-YYYY"},
+				"total": M{"type": "integer"},
+			})))),
+	},
+	"/counters/:id/sum": {
+		fiber.MethodGet: operation("statistics", "Total of a counter", counterOptions, nil,
+			responses("200", "Total", ref("Sum"))),
+	},
+	"/counters/:id/avg": {
+		fiber.MethodGet: operation("statistics", "Daily average of a counter", counterOptions, nil,
+			responses("200", "Average", ref("Avg"))),
+	},
+	"/counters/:id/stats": {
+		fiber.MethodGet: operation("statistics", "Statistics of a counter",
+			withCounterOptions(
+				query("extended", "add the distribution of entries and daily totals", M{"type": "boolean"}),
+				paramRef("If-None-Match"),
+			), nil,
+			responses("200", "Statistics", ref("Stats"))),
+	},
+	"/counters/:id/series": {
+		fiber.MethodGet: operation("statistics", "Time bucketed series of a counter, empty buckets are zero",
+			withCounterOptions(paramRef("interval")), nil,
+			responses("200", "Buckets ordered by date", arrayOf(ref("SeriesBucket")))),
+	},
+	"/counters/:id/calendar": {
+		fiber.MethodGet: operation("statistics", "Calendar heatmap of a counter, one year at most",
+			withCounterOptions(
+				query("year", "calendar year, the last year by default", M{"type": "integer"}),
+				query("quantiles", "comma separated quantiles of the levels", M{"type": "string", "default": "0.25,0.5,0.75"}),
+			), nil,
+			responses("200", "Calendar", ref("Calendar"))),
+	},
+	"/counters/:id/trend": {
+		fiber.MethodGet: operation("statistics", "Moving averages and trend of a counter",
+			withCounterOptions(query("window", "days of the regression", M{"type": "integer", "minimum": 2, "maximum": 365, "default": 30})), nil,
+			responses("200", "Trend", ref("Trend"))),
+	},
+	"/counters/:id/forecast": {
+		fiber.MethodGet: operation("statistics", "Forecast of a counter",
+			withCounterOptions(
+				query("interval", "bucket size", M{"type": "string", "enum": []string{"day", "week"}, "default": "day"}),
+				query("horizon", "buckets to forecast", M{"type": "integer", "minimum": 1, "maximum": 365, "default": 14}),
+				query("target", "total whose eta is estimated", M{"type": "number"}),
+			), nil,
+			responses("200", "Forecast", ref("Forecast"))),
+	},
+	"/dashboard": {
+		fiber.MethodGet: operation("statistics", "Every counter with its summary",
+			[]M{
+				paramRef("global"),
+				query("tz", "timezone overriding the one of every counter", M{"type": "string"}),
+				query("days", "days of the sparklines", M{"type": "integer", "minimum": 1, "maximum": 90, "default": 14}),
+			}, nil,
+			responses("200", "Dashboard", arrayOf(ref("DashboardCounter")))),
+	},
+	"/feed": {
+		fiber.MethodGet: operation("data", "Latest data grouped by day",
+			[]M{
+				query("counter", "comma separated counter ids", M{"type": "string"}),
+				query("day", "only this day, YYYY-MM-DD", M{"type": "string", "format": "date"}),
+				query("cursor", "nextCursor of the previous page", M{"type": "string"}),
+				query("limit", "entries per page", M{"type": "integer", "minimum": 1, "maximum": 200, "default": 50}),
+				paramRef("tz"),
+			}, nil,
+			responses("200", "Feed page", ref("Feed"))),
+	},
+	"/export": {
+		fiber.MethodGet: operation("export", "Export the data of some or all counters",
+			[]M{
+				query("counters", "comma separated counter ids or slugs, every counter by default", M{"type": "string"}),
+				paramRef("format"),
+				paramRef("global"),
+				paramRef("range"),
+				paramRef("from"),
+				paramRef("to"),
+				query("tz", "timezone overriding the one of every counter", M{"type": "string"}),
+			}, nil, exportResponses()),
+	},
+	"/compare": {
+		fiber.MethodGet: operation("statistics", "Aligned series and correlations of counters",
+			[]M{
+				query("ids", "2 to 10 comma separated counter ids", M{"type": "string"}),
+				paramRef("interval"),
+				query("maxLag", "largest lag of the lagged correlations", M{"type": "integer", "minimum": 0, "maximum": 30, "default": 7}),
+				paramRef("global"),
+				paramRef("range"),
+				paramRef("from"),
+				paramRef("to"),
+				paramRef("tz"),
+			}, nil,
+			responses("200", "Comparison", ref("Comparison"))),
+	},
+	"/datas": {
+		fiber.MethodGet: operation("data", "List data",
+			[]M{
+				query("o", "sort key, prefixed by - for descending order", M{"type": "string", "enum": []string{
+					"createdAt", "-createdAt", "updatedAt", "-updatedAt", "number", "-number", "counterRef", "-counterRef",
+				}}),
+				query("limit", "maximum number of data, 0 for all", M{"type": "integer", "default": 0}),
+				query("counter", "comma separated counter ids", M{"type": "string"}),
+				query("from", "created at or after", M{"type": "string"}),
+				query("to", "created before", M{"type": "string"}),
+				query("min", "minimum number", M{"type": "integer"}),
+				query("max", "maximum number", M{"type": "integer"}),
+				query("tags", "comma separated tags, all of them must match", M{"type": "string"}),
+				paramRef("tz"),
+			}, nil,
+			responses("200", "Data", arrayOf(ref("Data")))),
+		fiber.MethodPost: operation("data", "Create a data", []M{paramRef("Idempotency-Key")}, jsonBody(ref("Data")),
+			responses("200", "Created data", ref("Data"))),
+	},
+	"/datas/:id": {
+		fiber.MethodGet: operation("data", "Get a data", []M{paramRef("id")}, nil,
+			responses("200", "Data", ref("Data"))),
+		fiber.MethodDelete: operation("data", "Delete a data", []M{paramRef("id")}, nil,
+			responses("200", "Deleted", object([]string{"success"}, M{"success": M{"type": "boolean", "const": true}}))),
+	},
+}
+
+// v2Responses replaces the responses of the operations whose shape changed
+// in v2.
+var v2Responses = map[string]map[string]M{
+	"/datas/:id": {
+		fiber.MethodDelete: responses("204", "Deleted", nil),
+	},
+}
+
+// rootSpec lists the documented operations outside of the versions, by
+// method and absolute fiber path. They are never enveloped.
+var rootSpec = map[string]map[string]M{
+	"/t/:token": {
+		fiber.MethodGet: operation("triggers", "Use a trigger URL, adds its entry unless within the cooldown",
+			[]M{{"name": "token", "in": "path", "required": true, "schema": M{"type": "string"}}}, nil,
+			M{
+				"200": M{"description": "Confirmation page", "content": M{fiber.MIMETextHTML: M{}}},
+				"303": M{"description": "Redirect of the trigger", "headers": M{"Location": M{"schema": M{"type": "string"}}}},
+				"404": M{"description": "Unknown or revoked trigger", "content": M{fiber.MIMETextHTML: M{}}},
+				"422": M{"description": "Entry out of the bounds of the counter", "content": M{fiber.MIMETextHTML: M{}}},
+				"429": M{
+					"description": "Used within the cooldown or over the rate limit",
+					"headers":     M{"Retry-After": M{"schema": M{"type": "integer"}}},
+					"content":     M{fiber.MIMETextHTML: M{}},
+				},
+			}),
+	},
+	"/graphql": {
+		fiber.MethodGet: operation("graphql", "Execute a GraphQL query, subscriptions are streamed as Server-Sent Events",
+			[]M{
+				query("query", "GraphQL document", M{"type": "string"}),
+				query("operationName", "operation of the document to execute", M{"type": "string"}),
+				query("variables", "JSON object of the variables", M{"type": "string"}),
+			}, nil, graphQLResponses),
+		fiber.MethodPost: operation("graphql", "Execute a GraphQL query, subscriptions are streamed as Server-Sent Events",
+			nil, jsonBody(object([]string{"query"}, M{
+				"query":         M{"type": "string"},
+				"operationName": M{"type": "string"},
+				"variables":     M{"type": "object"},
+			})), graphQLResponses),
+	},
+}
+
+var graphQLResponses = M{
+	"200": M{
+		"description": "GraphQL result, or the results of a subscription when 'Accept: text/event-stream'",
+		"content": M{
+			fiber.MIMEApplicationJSON: M{"schema": object(nil, M{
+				"data":   M{"type": []string{"object", "null"}},
+				"errors": arrayOf(M{"type": "object"}),
+			})},
+			"text/event-stream": M{"schema": M{"type": "string"}},
+		},
+	},
+	"default": M{"$ref": "#/components/responses/Error"},
+}
+
+var statsVersion = M{"type": "integer", "const": queries.StatsVersion, "description": "version of the response shape"}
+
+var summarySchema = object(nil, M{
+	"count":  M{"type": "integer"},
+	"min":    M{"type": "number"},
+	"max":    M{"type": "number"},
+	"mean":   M{"type": "number"},
+	"median": M{"type": "number"},
+	"p90":    M{"type": "number"},
+	"p95":    M{"type": "number"},
+	"stdDev": M{"type": "number"},
+})
+
+var schemas = M{
+	"Counter": object([]string{"name"}, M{
+		"id":        M{"type": "string", "readOnly": true},
+		"name":      M{"type": "string", "maxLength": 100},
+		"slug":      M{"type": "string", "maxLength": 100, "pattern": "^[a-z0-9]+(-[a-z0-9]+)*$", "description": "unique, generated from the name"},
+		"softReset": M{"type": "string", "format": "date-time"},
+		"timezone":  M{"type": "string", "description": "IANA timezone of the statistics"},
+		"color":     M{"type": "string", "pattern": "^#[0-9a-fA-F]{6}$"},
+		"min":       M{"type": "integer", "description": "smallest number of a data"},
+		"max":       M{"type": "integer", "description": "largest number of a data"},
+		"createdAt": M{"type": "string", "format": "date-time", "readOnly": true},
+		"updatedAt": M{"type": "string", "format": "date-time", "readOnly": true},
+	}),
+	"Data": object([]string{"number", "counterRef"}, M{
+		"id":         M{"type": "string", "readOnly": true},
+		"number":     M{"type": "integer"},
+		"counterRef": M{"type": "string"},
+		"tags":       arrayOf(M{"type": "string"}),
+		"createdAt":  M{"type": "string", "format": "date-time"},
+		"updatedAt":  M{"type": "string", "format": "date-time", "readOnly": true},
+	}),
+	"ExportRow": object([]string{"id", "counterId", "counterSlug", "counterName", "number", "tags", "createdAt", "updatedAt"}, M{
+		"id":          M{"type": "string"},
+		"counterId":   M{"type": "string"},
+		"counterSlug": M{"type": "string"},
+		"counterName": M{"type": "string", "description": "prefixed with ' in CSV when it starts with =, +, -, @, a tab or a carriage return"},
+		"number":      M{"type": "integer"},
+		"tags":        M{"type": "array", "items": M{"type": "string"}, "description": "separated by ; in CSV, prefixed with ' as the name"},
+		"createdAt":   M{"type": "string", "format": "date-time", "description": "in the requested timezone"},
+		"updatedAt":   M{"type": "string", "format": "date-time", "description": "in the requested timezone"},
+	}),
+	"Error": object([]string{"error", "code", "msg"}, M{
+		"error": M{"type": "boolean", "const": true},
+		"code":  M{"type": "string"},
+		"msg":   M{"type": "string"},
+		"fields": arrayOf(object(nil, M{
+			"field": M{"type": "string"},
+			"rule":  M{"type": "string"},
+			"msg":   M{"type": "string"},
+		})),
+		"current": M{"description": "current resource when a precondition failed"},
+	}),
+	"Summary": summarySchema,
+	"Sum": object([]string{"version", "counterId", "total"}, M{
+		"version":   statsVersion,
+		"counterId": M{"type": "string"},
+		"total":     M{"type": "integer"},
+	}),
+	"Avg": object([]string{"version", "counterId", "avg", "days"}, M{
+		"version":   statsVersion,
+		"counterId": M{"type": "string"},
+		"avg":       M{"type": "number", "description": "total per day"},
+		"days":      M{"type": "integer"},
+	}),
+	"Stats": object([]string{"version", "counterId", "total", "avg", "days"}, M{
+		"version":    statsVersion,
+		"counterId":  M{"type": "string"},
+		"total":      M{"type": "integer"},
+		"avg":        M{"type": "number", "description": "total per day"},
+		"days":       M{"type": "integer"},
+		"perDay":     ref("Summary"),
+		"perEntry":   ref("Summary"),
+		"busiestDay": ref("SeriesBucket"),
+	}),
+	"Trigger": object([]string{"number"}, M{
+		"id":         M{"type": "string", "readOnly": true},
+		"counterRef": M{"type": "string", "readOnly": true},
+		"name":       M{"type": "string", "maxLength": 100},
+		"number":     M{"type": "integer", "default": 1, "description": "number of the created entries"},
+		"tags":       arrayOf(M{"type": "string"}),
+		"redirect":   M{"type": "string", "description": "http(s) URL to redirect to instead of the confirmation page"},
+		"tokenHint":  M{"type": "string", "readOnly": true, "description": "first characters of the token"},
+		"lastUsedAt": M{"type": "string", "readOnly": true},
+		"createdAt":  M{"type": "string", "readOnly": true},
+	}),
+	"Increment": object([]string{"entry", "stats"}, M{
+		"entry": ref("Data"),
+		"stats": ref("Stats"),
+	}),
+	"SeriesBucket": object(nil, M{
+		"date":  M{"type": "string", "description": "ISO date, or date-time for hours"},
+		"sum":   M{"type": "integer"},
+		"count": M{"type": "integer"},
+		"avg":   M{"type": "number"},
+		"min":   M{"type": "integer"},
+		"max":   M{"type": "integer"},
+	}),
+	"Calendar": object(nil, M{
+		"from":       M{"type": "string", "format": "date"},
+		"to":         M{"type": "string", "format": "date"},
+		"max":        M{"type": "integer"},
+		"thresholds": arrayOf(M{"type": "number"}),
+		"days": arrayOf(object(nil, M{
+			"date":  M{"type": "string", "format": "date"},
+			"total": M{"type": "integer"},
+			"level": M{"type": "integer"},
+		})),
+	}),
+	"Trend": object(nil, M{
+		"days":       M{"type": "integer"},
+		"ma7":        nullable("number"),
+		"ma30":       nullable("number"),
+		"ma90":       nullable("number"),
+		"window":     M{"type": "integer"},
+		"slope":      M{"type": "number"},
+		"r2":         M{"type": "number"},
+		"direction":  M{"type": "string", "enum": []string{"rising", "falling", "stable"}},
+		"confidence": M{"type": "number"},
+		"changePct":  nullable("number"),
+	}),
+	"Forecast": object(nil, M{
+		"interval": M{"type": "string"},
+		"model":    M{"type": "string", "enum": []string{"linear", "linear+weekly"}},
+		"history":  M{"type": "integer"},
+		"points": arrayOf(object(nil, M{
+			"date":  M{"type": "string", "format": "date"},
+			"value": M{"type": "number"},
+			"lower": M{"type": "number"},
+			"upper": M{"type": "number"},
+		})),
+		"target": object(nil, M{
+			"target":  M{"type": "number"},
+			"total":   M{"type": "number"},
+			"reached": M{"type": "boolean"},
+			"eta":     nullable("string"),
+		}),
+	}),
+	"Comparison": object(nil, M{
+		"interval": M{"type": "string"},
+		"dates":    arrayOf(M{"type": "string"}),
+		"series": arrayOf(object(nil, M{
+			"id":     M{"type": "string"},
+			"name":   M{"type": "string"},
+			"values": arrayOf(M{"type": "number"}),
+		})),
+		"correlations": arrayOf(object(nil, M{
+			"a":        M{"type": "string"},
+			"b":        M{"type": "string"},
+			"pearson":  nullable("number"),
+			"spearman": nullable("number"),
+			"lagged": arrayOf(object(nil, M{
+				"lag":     M{"type": "integer"},
+				"pearson": nullable("number"),
+			})),
+			"bestLag": nullable("integer"),
+		})),
+	}),
+	"DashboardCounter": M{"allOf": []M{ref("Counter"), object(nil, M{
+		"stats": object(nil, M{
+			"total": M{"type": "integer"},
+			"avg":   M{"type": "number"},
+			"days":  M{"type": "integer"},
+		}),
+		"lastEntry": M{"oneOf": []M{ref("Data"), {"type": "null"}}},
+		"today":     M{"type": "integer"},
+		"sparkline": arrayOf(object(nil, M{
+			"date":  M{"type": "string", "format": "date"},
+			"total": M{"type": "integer"},
+		})),
+	})}},
+	"Feed": object(nil, M{
+		"days": arrayOf(object(nil, M{
+			"date":  M{"type": "string", "format": "date"},
+			"total": M{"type": "integer"},
+			"count": M{"type": "integer"},
+			"entries": arrayOf(M{"allOf": []M{ref("Data"), object(nil, M{
+				"counter": object(nil, M{
+					"id":    M{"type": "string"},
+					"name":  M{"type": "string"},
+					"color": M{"type": "string"},
+				}),
+			})}}),
+		})),
+		"nextCursor": nullable("string"),
+	}),
+}
+
+var parameters = M{
+	"id":     M{"name": "id", "in": "path", "required": true, "description": "id, or slug of a counter", "schema": M{"type": "string"}},
+	"format": query("format", "format of the export", M{"type": "string", "enum": []string{"csv", "json", "ndjson"}, "default": "csv"}),
+	"global": query("global", "ignore the soft reset of the counter", M{"type": "boolean"}),
+	"range": query("range", "preset range, from and to override its bounds",
+		M{"type": "string", "enum": utils.DateRangePresets}),
+	"from": query("from", "range start, RFC3339 or YYYY-MM-DD in tz", M{"type": "string"}),
+	"to":   query("to", "range end, excluded, RFC3339 or YYYY-MM-DD in tz", M{"type": "string"}),
+	"tz":   query("tz", "IANA timezone, the one of the counter or UTC by default", M{"type": "string"}),
+	"interval": query("interval", "bucket size",
+		M{"type": "string", "enum": utils.Intervals, "default": "day"}),
+	"Idempotency-Key": header("Idempotency-Key", "retries with the same key return the first response"),
+	"If-Match":        header("If-Match", "ETag of the counter, 412 when it changed"),
+	"If-None-Match":   header("If-None-Match", "ETag of a previous response, 304 when unchanged"),
+}
+
+// openAPIPath converts a fiber path to an OpenAPI one.
+func openAPIPath(path string) string {
+	return pathParam.ReplaceAllString(path, "{$1}")
+}
+
+// versions lists the prefixes of the API versions, the enveloped ones wrap
+// their responses in a response.Envelope.
+var versions = map[string]bool{
+	"/api/v1": false,
+	"/api/v2": true,
+}
+
+var envelopeError = object([]string{"code", "msg"}, M{
+	"code":  M{"type": "string"},
+	"msg":   M{"type": "string"},
+	"field": M{"type": "string"},
+	"rule":  M{"type": "string"},
+})
+
+func envelope(data M) M {
+	return object([]string{"data", "meta", "errors"}, M{
+		"data":   data,
+		"meta":   M{"type": "object", "description": "pagination and range of the response"},
+		"errors": arrayOf(envelopeError),
+	})
+}
+
+// envelopeResponses wraps the successful JSON responses of an operation.
+func envelopeResponses(op M) M {
+	op = maps.Clone(op)
+	wrapped := M{}
+	for status, response := range op["responses"].(M) {
+		response := response.(M)
+		if !strings.HasPrefix(status, "2") {
+			wrapped[status] = response
+			continue
+		}
+
+		// the responses without content and the streamed exports are not
+		// enveloped
+		content, ok := response["content"].(M)
+		if !ok || len(content) > 1 {
+			wrapped[status] = response
+			continue
+		}
+
+		wrapped["200"] = M{
+			"description": response["description"],
+			"content":     M{fiber.MIMEApplicationJSON: M{"schema": envelope(content[fiber.MIMEApplicationJSON].(M)["schema"].(M))}},
+		}
+	}
+	op["responses"] = wrapped
+
+	return op
+}
+
+func openAPIDocument(prefix string) M {
+	enveloped := versions[prefix]
+
+	errorSchema := ref("Error")
+	if enveloped {
+		errorSchema = envelope(M{"description": "current resource when a precondition failed"})
+	}
+
+	paths := M{}
+	for path, operations := range spec {
+		item := M{}
+		for method, op := range operations {
+			if responses, ok := v2Responses[path][method]; ok && enveloped {
+				op = maps.Clone(op)
+				op["responses"] = responses
+			}
+			if enveloped && !slices.Contains(op["tags"].([]string), "docs") {
+				op = envelopeResponses(op)
+			}
+			item[strings.ToLower(method)] = op
+		}
+		paths[openAPIPath(prefix+path)] = item
+	}
+	for path, operations := range rootSpec {
+		item := M{}
+		for method, op := range operations {
+			item[strings.ToLower(method)] = op
+		}
+		paths[openAPIPath(path)] = item
+	}
+
+	info := M{"title": "GO MERN COUNTER", "version": "1.0.0"}
+	if !enveloped {
+		info["description"] = "Deprecated, use /api/v2."
+	}
+
+	return M{
+		"openapi": "3.1.0",
+		"info":    info,
+		"paths":   paths,
+		"components": M{
+			"schemas":    schemas,
+			"parameters": parameters,
+			"responses": M{
+				"Error": M{
+					"description": "Error",
+					"content":     M{fiber.MIMEApplicationJSON: M{"schema": errorSchema}},
+				},
+			},
+		},
+	}
+}
+
+// checkSpec fails when a route of the app has no operation in the spec and
+// is not undocumented.
+func checkSpec(a *fiber.App) error {
+	var missing []string
+	for _, route := range a.GetRoutes(true) {
+		if route.Method == fiber.MethodHead || undocumented[route.Method+" "+route.Path] {
+			continue
+		}
+
+		operations, ok := rootSpec[route.Path]
+		for prefix := range versions {
+			if relative, found := strings.CutPrefix(route.Path, prefix); found {
+				operations, ok = spec[relative], true
+			}
+		}
+		if _, documented := operations[route.Method]; !ok || !documented {
+			missing = append(missing, route.Method+" "+route.Path)
+		}
+	}
+
+	if len(missing) != 0 {
+		slices.Sort(missing)
+		return fmt.Errorf("routes missing from the OpenAPI spec: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// setDocsRoutes serves the documentation of a version, outside of its group
+// so that the document is never enveloped.
+func setDocsRoutes(a *fiber.App, prefix string) {
+	document := openAPIDocument(prefix)
+	page := strings.NewReplacer("{{openapi}}", prefix+"/openapi.json", "{{assets}}", docsAssets).Replace(docsPage)
+
+	a.Get(prefix+"/openapi.json", func(c *fiber.Ctx) error {
+		return c.JSON(document)
+	})
+	a.Get(prefix+"/docs", func(c *fiber.Ctx) error {
+		c.Type("html")
+		return c.SendString(page)
+	})
+}
+
+// setDocsAssetsRoute serves the files of the docs page.
+func setDocsAssetsRoute(a *fiber.App) {
+	a.Get(docsAssets+"/*", func(c *fiber.Ctx) error {
+		name := c.Params("*")
+		file, err := docsFiles.ReadFile("docs/" + name)
+		if err != nil {
+			return apierror.NotFound("file not found")
+		}
+
+		c.Type(strings.TrimPrefix(path.Ext(name), "."))
+		c.Set(fiber.HeaderCacheControl, "public, max-age=86400")
+		return c.Send(file)
+	})
+}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"main/app/pkg/db"
+	"main/app/queries"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestEveryRouteIsInTheSpec(t *testing.T) {
+	// the idempotency storage is only used by the requests
+	db.Q.Idempotency = &queries.IdempotencyQueries{}
+
+	app := fiber.New()
+	SetRoutes(app)
+
+	if err := checkSpec(app); err != nil {
+		t.Fatal(err)
+	}
+}
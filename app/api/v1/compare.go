@@ -0,0 +1,72 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"main/app/models"
+	"main/app/pkg/apierror"
+	"main/app/pkg/db"
+	"main/app/pkg/utils"
+	"main/app/queries"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetCompare(c *fiber.Ctx) error {
+	ids, err := queryObjectIDs(c, "ids")
+	if err != nil {
+		return err
+	}
+	if len(ids) < 2 || len(ids) > 10 {
+		return apierror.InvalidParameter("'ids': expected between 2 and 10 counters")
+	}
+	for i, id := range ids {
+		if slices.Contains(ids[:i], id) {
+			return apierror.InvalidParameter(fmt.Sprintf("'ids': counter '%s' is repeated", id.Hex()))
+		}
+	}
+
+	counters, err := db.Q.GetCountersByIDs(ids)
+	if err != nil {
+		return err
+	}
+	if len(counters) != len(ids) {
+		return apierror.NotFound("'ids': some counters do not exist")
+	}
+	slices.SortFunc(counters, func(a, b models.Counter) int {
+		return slices.Index(ids, a.ID) - slices.Index(ids, b.ID)
+	})
+
+	// without tz the counters are compared in UTC
+	opts, err := queryCounterOptions(c, models.Counter{})
+	if err != nil {
+		return err
+	}
+
+	interval := strings.TrimSpace(c.Query("interval", "day"))
+	if !slices.Contains(utils.Intervals, interval) {
+		return apierror.InvalidParameter(fmt.Sprintf("'interval': invalid interval '%s', expected one of %s", interval, strings.Join(utils.Intervals, ", ")))
+	}
+
+	maxLag := 7
+	if value, err := queryInt(c, "maxLag"); err != nil {
+		return err
+	} else if value != nil {
+		maxLag = *value
+	}
+	if maxLag < 0 || maxLag > 30 {
+		return apierror.InvalidParameter("'maxLag': expected a number between 0 and 30")
+	}
+
+	comparison, err := db.Q.CompareCounters(counters, interval, maxLag, opts)
+	if errors.Is(err, queries.ErrTooManyBuckets) {
+		return apierror.InvalidParameter(err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(comparison)
+}
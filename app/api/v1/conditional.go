@@ -0,0 +1,106 @@
+package v1
+
+import (
+	"fmt"
+	"hash/fnv"
+	"main/app/models"
+	"main/app/pkg/apierror"
+	. "main/app/pkg/configs"
+	"main/app/pkg/db"
+	"main/app/pkg/utils"
+	"main/app/queries"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// checkIfMatch fails with 412 and the current counter when the If-Match
+// header doesn't match it. A missing header is accepted unless
+// concurrency.requireIfMatch is set.
+func checkIfMatch(c *fiber.Ctx, counter models.Counter) error {
+	ifMatch := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if ifMatch == "" {
+		if Configs.Bool("concurrency.requireIfMatch") {
+			return apierror.PreconditionRequired("the 'If-Match' header is required")
+		}
+
+		return nil
+	}
+	if ifMatch == "*" {
+		return nil
+	}
+
+	etag := counter.ETag()
+	for _, tag := range strings.Split(ifMatch, ",") {
+		// weak tags never match with If-Match
+		if strings.TrimSpace(tag) == etag {
+			return nil
+		}
+	}
+
+	c.Set(fiber.HeaderETag, etag)
+	return apierror.PreconditionFailed(counter)
+}
+
+func weakETag(parts ...any) string {
+	hash := fnv.New64a()
+	fmt.Fprint(hash, parts...)
+
+	return fmt.Sprintf(`W/"%x"`, hash.Sum64())
+}
+
+// notModified sets the validators of the response and reports whether the
+// request preconditions match them, in which case a 304 should be sent.
+// If-None-Match takes precedence over If-Modified-Since.
+func notModified(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	c.Set(fiber.HeaderETag, etag)
+	if !lastModified.IsZero() {
+		c.Set(fiber.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if ifNoneMatch := strings.TrimSpace(c.Get(fiber.HeaderIfNoneMatch)); ifNoneMatch != "" {
+		if ifNoneMatch == "*" {
+			return true
+		}
+
+		// If-None-Match uses the weak comparison
+		opaque := strings.TrimPrefix(etag, "W/")
+		for _, tag := range strings.Split(ifNoneMatch, ",") {
+			if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == opaque {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if ifModifiedSince := c.Get(fiber.HeaderIfModifiedSince); ifModifiedSince != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		return err == nil && !lastModified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
+
+// counterDataNotModified checks the preconditions of the responses computed
+// from the data of counter. They also change every day since the statistics
+// depend on the current date.
+func counterDataNotModified(c *fiber.Ctx, counter models.Counter, opts queries.CounterOptions) (bool, error) {
+	version, err := db.Q.GetCounterDataVersion(counter)
+	if err != nil {
+		return false, err
+	}
+
+	today := utils.StartOfDay(time.Now().In(opts.Location))
+	lastModified := counter.UpdatedAt.Time()
+	for _, t := range []time.Time{version.LastModified, today} {
+		if t.After(lastModified) {
+			lastModified = t
+		}
+	}
+
+	etag := weakETag(counter.ID.Hex(), int64(counter.UpdatedAt), version.LastModified.UnixMilli(), version.Count, today.Unix())
+	return notModified(c, etag, lastModified), nil
+}
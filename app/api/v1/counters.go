@@ -1,47 +1,73 @@
 package v1
 
 import (
+	"errors"
+	"fmt"
 	"main/app/models"
+	"main/app/pkg/apierror"
 	"main/app/pkg/db"
 	"main/app/pkg/utils"
+	"main/app/pkg/validation"
 	"main/app/queries"
+	"math"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func CreateCounter(c *fiber.Ctx) error {
 	var counter models.Counter
 
 	if err := c.BodyParser(&counter); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return apierror.BadRequest(apierror.CodeInvalidBody, "cannot parse the request body")
+	}
+
+	if fields := validation.Struct(counter); fields != nil {
+		return apierror.Validation(fields)
 	}
 
 	counter.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
 	counter.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
 
 	dbdata, err := db.Q.CreateCounter(counter)
+	if mongo.IsDuplicateKeyError(err) {
+		return errSlugUsed
+	}
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
 	}
 
+	c.Set(fiber.HeaderETag, dbdata.ETag())
 	return c.JSON(dbdata)
 }
 
+var errSlugUsed = apierror.Conflict("'slug' is already used by another counter")
+
 func GetCounters(c *fiber.Ctx) error {
-	counters, err := db.Q.GetCounters()
+	version, err := db.Q.GetCountersVersion()
+	if err != nil {
+		return err
+	}
+	if notModified(c, weakETag(version.LastModified.UnixMilli(), version.Count), version.LastModified) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	var counters []models.Counter
+	if query := strings.TrimSpace(c.Query("q", "")); query != "" {
+		counters, err = db.Q.SearchCounters(query)
+	} else {
+		counters, err = db.Q.GetCounters()
+	}
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
+	}
+
+	if len(counters) == 0 {
+		return c.JSON([]interface{}{})
 	}
 
 	return c.JSON(counters)
@@ -50,10 +76,11 @@ func GetCounters(c *fiber.Ctx) error {
 func GetCounter(c *fiber.Ctx) error {
 	counter, err := db.Q.GetCounter(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
+	}
+
+	if notModified(c, counter.ETag(), counter.UpdatedAt.Time()) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
 	return c.JSON(counter)
@@ -62,20 +89,20 @@ func GetCounter(c *fiber.Ctx) error {
 func EditCounter(c *fiber.Ctx) error {
 	counter, err := db.Q.GetCounter(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
+	}
+	if err := checkIfMatch(c, counter); err != nil {
+		return err
 	}
+	previous := counter.UpdatedAt
 
 	var updatedData map[string]interface{}
 	if err := c.BodyParser(&updatedData); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Cannot parse JSON",
-		})
+		return apierror.BadRequest(apierror.CodeInvalidBody, "cannot parse the request body")
 	}
 
 	if len(updatedData) == 0 {
+		c.Set(fiber.HeaderETag, counter.ETag())
 		return c.Status(fiber.StatusNotModified).JSON(counter)
 	}
 
@@ -90,43 +117,114 @@ func EditCounter(c *fiber.Ctx) error {
 	if name, ok := updatedData["name"].(string); ok && name != "" {
 		counter.Name = name
 	}
+	// a cleared slug is generated from the name again
+	if slug, ok := updatedData["slug"]; slug == nil && ok {
+		counter.Slug = ""
+	} else if slug, ok := updatedData["slug"].(string); ok {
+		counter.Slug = slug
+	}
+	if timezone, ok := updatedData["timezone"].(string); ok {
+		counter.Timezone = timezone
+	}
+	if color, ok := updatedData["color"].(string); ok {
+		counter.Color = color
+	}
+
+	var fields []validation.FieldError
+	bounds := []struct {
+		key   string
+		value **int
+	}{{"min", &counter.Min}, {"max", &counter.Max}}
+	for _, bound := range bounds {
+		key := bound.key
+		value, ok := updatedData[key]
+		if !ok {
+			continue
+		}
+
+		if number, isNumber := value.(float64); value == nil {
+			*bound.value = nil
+		} else if isNumber && number == math.Trunc(number) {
+			n := int(number)
+			*bound.value = &n
+		} else {
+			fields = append(fields, validation.FieldError{Field: key, Rule: "number", Msg: fmt.Sprintf("'%s' must be an integer or null", key)})
+		}
+	}
+
+	fields = append(fields, validation.Struct(counter)...)
+	if len(fields) != 0 {
+		return apierror.Validation(fields)
+	}
 
 	counter.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
-	if ok, err := db.Q.EditCounter(counter); !ok || err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(err)
+	ok, err := db.Q.EditCounter(&counter, previous)
+	if mongo.IsDuplicateKeyError(err) {
+		return errSlugUsed
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return concurrentCounterChange(c, counter.ID.Hex())
 	}
 
+	c.Set(fiber.HeaderETag, counter.ETag())
 	return c.JSON(counter)
 }
 
 func DeleteCounter(c *fiber.Ctx) error {
-	err := db.Q.DeleteCounter(c.Params("id"))
+	counter, err := db.Q.GetCounter(c.Params("id"))
+	if err != nil {
+		return err
+	}
+	if err := checkIfMatch(c, counter); err != nil {
+		return err
+	}
+
+	ok, err := db.Q.DeleteCounter(counter)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
+	}
+	if !ok {
+		return concurrentCounterChange(c, counter.ID.Hex())
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
+// concurrentCounterChange reports a write that lost against a concurrent
+// one, with the current counter if it still exists.
+func concurrentCounterChange(c *fiber.Ctx, id string) error {
+	current, err := db.Q.GetCounter(id)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderETag, current.ETag())
+	return apierror.PreconditionFailed(current)
+}
+
 func GetCounterData(c *fiber.Ctx) error {
 	counter, err := db.Q.GetCounter(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
 	}
 
-	global := utils.StringToBool(c.Query("global", ""))
-	counters, err := db.Q.GetCounterData(counter, queries.CounterOptions{Global: global})
+	opts, err := queryCounterOptions(c, counter)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
+	}
+
+	if fresh, err := counterDataNotModified(c, counter, opts); err != nil {
+		return err
+	} else if fresh {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	counters, err := db.Q.GetCounterData(counter, opts)
+	if err != nil {
+		return err
 	}
 
 	if len(counters) == 0 {
@@ -139,19 +237,17 @@ func GetCounterData(c *fiber.Ctx) error {
 func GetCounterSum(c *fiber.Ctx) error {
 	counter, err := db.Q.GetCounter(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
+	}
+
+	opts, err := queryCounterOptions(c, counter)
+	if err != nil {
+		return err
 	}
 
-	global := utils.StringToBool(c.Query("global", ""))
-	counters, err := db.Q.GetCounterSum(counter, queries.CounterOptions{Global: global})
+	counters, err := db.Q.GetCounterSum(counter, opts)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(counters)
@@ -160,19 +256,17 @@ func GetCounterSum(c *fiber.Ctx) error {
 func GetCounterAvg(c *fiber.Ctx) error {
 	counter, err := db.Q.GetCounter(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
 	}
 
-	global := utils.StringToBool(c.Query("global", ""))
-	avg, err := db.Q.GetCounterAvg(counter, queries.CounterOptions{Global: global})
+	opts, err := queryCounterOptions(c, counter)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
+	}
+
+	avg, err := db.Q.GetCounterAvg(counter, opts)
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(avg)
@@ -180,41 +274,200 @@ func GetCounterAvg(c *fiber.Ctx) error {
 func GetCounterStats(c *fiber.Ctx) error {
 	counter, err := db.Q.GetCounter(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
 	}
 
-	global := utils.StringToBool(c.Query("global", ""))
-	avg, err := db.Q.GetCounterStats(counter, queries.CounterOptions{Global: global})
+	opts, err := queryCounterOptions(c, counter)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
 	}
 
-	return c.JSON(avg)
+	if fresh, err := counterDataNotModified(c, counter, opts); err != nil {
+		return err
+	} else if fresh {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	stats, err := db.Q.GetCounterStats(counter, opts)
+	if err != nil {
+		return err
+	}
+
+	if utils.StringToBool(c.Query("extended", "")) {
+		distribution, err := db.Q.GetCounterDistribution(counter, opts)
+		if err != nil {
+			return err
+		}
+
+		stats.PerDay = &distribution.PerDay
+		stats.PerEntry = &distribution.PerEntry
+		stats.BusiestDay = distribution.BusiestDay
+	}
+
+	return c.JSON(stats)
 }
 
 func GetCounterDataByMonth(c *fiber.Ctx) error {
 	counter, err := db.Q.GetCounter(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
 	}
 
-	global := utils.StringToBool(c.Query("global", ""))
-	counters, err := db.Q.GetCounterDataByMonth(counter, queries.CounterOptions{Global: global})
+	opts, err := queryCounterOptions(c, counter)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
+	}
+
+	counters, err := db.Q.GetCounterDataByMonth(counter, opts)
+	if err != nil {
+		return err
 	}
 
 	return c.JSON(counters)
 }
+
+func GetCounterSeries(c *fiber.Ctx) error {
+	counter, err := db.Q.GetCounter(c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	opts, err := queryCounterOptions(c, counter)
+	if err != nil {
+		return err
+	}
+
+	interval := strings.TrimSpace(c.Query("interval", "day"))
+	if !slices.Contains(utils.Intervals, interval) {
+		return apierror.InvalidParameter(fmt.Sprintf("'interval': invalid interval '%s', expected one of %s", interval, strings.Join(utils.Intervals, ", ")))
+	}
+
+	series, err := db.Q.GetCounterSeries(counter, interval, opts)
+	if errors.Is(err, queries.ErrTooManyBuckets) {
+		return apierror.InvalidParameter(err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(series)
+}
+
+func GetCounterCalendar(c *fiber.Ctx) error {
+	counter, err := db.Q.GetCounter(c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	opts, err := queryCounterOptions(c, counter)
+	if err != nil {
+		return err
+	}
+
+	year, err := queryInt(c, "year")
+	if err != nil {
+		return err
+	}
+	if year != nil {
+		from := time.Date(*year, time.January, 1, 0, 0, 0, 0, opts.Location)
+		to := from.AddDate(1, 0, 0)
+		opts.From, opts.To = &from, &to
+	}
+	if opts.To == nil {
+		to := utils.StartOfDay(time.Now().In(opts.Location)).AddDate(0, 0, 1)
+		opts.To = &to
+	}
+	if opts.From == nil {
+		from := opts.To.AddDate(-1, 0, 0)
+		opts.From = &from
+	}
+	if opts.To.Sub(*opts.From) > 366*24*time.Hour {
+		return apierror.InvalidParameter("the calendar range may not exceed one year")
+	}
+
+	quantiles, err := queryFloats(c, "quantiles")
+	if err != nil {
+		return err
+	}
+	if len(quantiles) == 0 {
+		quantiles = []float64{0.25, 0.5, 0.75}
+	}
+	if !slices.IsSorted(quantiles) || quantiles[0] <= 0 || quantiles[len(quantiles)-1] >= 1 {
+		return apierror.InvalidParameter("'quantiles': expected ascending values between 0 and 1")
+	}
+
+	calendar, err := db.Q.GetCounterCalendar(counter, quantiles, opts)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(calendar)
+}
+
+func GetCounterTrend(c *fiber.Ctx) error {
+	counter, err := db.Q.GetCounter(c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	opts, err := queryCounterOptions(c, counter)
+	if err != nil {
+		return err
+	}
+
+	window := 30
+	if value, err := queryInt(c, "window"); err != nil {
+		return err
+	} else if value != nil {
+		window = *value
+	}
+	if window < 2 || window > 365 {
+		return apierror.InvalidParameter("'window': expected a number of days between 2 and 365")
+	}
+
+	trend, err := db.Q.GetCounterTrend(counter, window, opts)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(trend)
+}
+
+func GetCounterForecast(c *fiber.Ctx) error {
+	counter, err := db.Q.GetCounter(c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	opts, err := queryCounterOptions(c, counter)
+	if err != nil {
+		return err
+	}
+
+	interval := strings.TrimSpace(c.Query("interval", "day"))
+	if interval != "day" && interval != "week" {
+		return apierror.InvalidParameter(fmt.Sprintf("'interval': invalid interval '%s', expected one of day, week", interval))
+	}
+
+	horizon := 14
+	if value, err := queryInt(c, "horizon"); err != nil {
+		return err
+	} else if value != nil {
+		horizon = *value
+	}
+	if horizon < 1 || horizon > 365 {
+		return apierror.InvalidParameter("'horizon': expected a number between 1 and 365")
+	}
+
+	target, err := queryFloat(c, "target")
+	if err != nil {
+		return err
+	}
+
+	forecast, err := db.Q.GetCounterForecast(counter, interval, horizon, target, opts)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(forecast)
+}
@@ -0,0 +1,42 @@
+package v1
+
+import (
+	"fmt"
+	"main/app/pkg/apierror"
+	"main/app/pkg/db"
+	"main/app/pkg/utils"
+	"main/app/queries"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetDashboard(c *fiber.Ctx) error {
+	opts := queries.DashboardOptions{
+		Global:        utils.StringToBool(c.Query("global", "")),
+		Timezone:      strings.TrimSpace(c.Query("tz", "")),
+		SparklineDays: 14,
+	}
+
+	if _, err := utils.LoadLocation(opts.Timezone); err != nil {
+		return apierror.InvalidParameter(fmt.Sprintf("'tz': invalid timezone '%s'", opts.Timezone))
+	}
+
+	days, err := queryInt(c, "days")
+	if err != nil {
+		return err
+	}
+	if days != nil {
+		if *days < 1 || *days > 90 {
+			return apierror.InvalidParameter("'days': expected a number between 1 and 90")
+		}
+		opts.SparklineDays = *days
+	}
+
+	dashboard, err := db.Q.GetDashboard(opts)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(dashboard)
+}
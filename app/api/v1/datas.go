@@ -1,8 +1,14 @@
 package v1
 
 import (
+	"errors"
+	"fmt"
 	"main/app/models"
+	"main/app/pkg/apierror"
 	"main/app/pkg/db"
+	"main/app/pkg/events"
+	"main/app/pkg/response"
+	"main/app/pkg/validation"
 	"main/app/queries"
 	"strconv"
 	"strings"
@@ -10,16 +16,22 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func CreateData(c *fiber.Ctx) error {
 	var data models.Data
 
 	if err := c.BodyParser(&data); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return apierror.BadRequest(apierror.CodeInvalidBody, "cannot parse the request body")
+	}
+
+	fields, err := validateData(data)
+	if err != nil {
+		return err
+	}
+	if fields != nil {
+		return apierror.Validation(fields)
 	}
 
 	// data.ID = primitive.NewObjectID()
@@ -30,25 +42,63 @@ func CreateData(c *fiber.Ctx) error {
 
 	dbdata, err := db.Q.CreateData(data)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
 	}
+	events.Entries.Publish(dbdata)
 
 	return c.JSON(dbdata)
 }
 
+// validateData checks the tags of data, then that its counter exists and
+// that the number is within the counter bounds.
+func validateData(data models.Data) ([]validation.FieldError, error) {
+	if fields := validation.Struct(data); fields != nil {
+		return fields, nil
+	}
+
+	counter, err := db.Q.GetCounter(data.Counter.Hex())
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return []validation.FieldError{{Field: "counterRef", Rule: "exists", Msg: "'counterRef' must be an existing counter"}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return validation.DataBounds(data, counter), nil
+}
+
 func GetDatas(c *fiber.Ctx) error {
 	order := strings.Trim(c.Query("o", ""), " ")
 	limit, _ := strconv.ParseInt(strings.Trim(c.Query("limit", "0"), " -"), 10, 64)
 
-	datas, err := db.Q.GetDatas(queries.ListOptions{Ordering: order, Limit: limit})
+	opts := queries.ListOptions{Ordering: order, Limit: limit, Tags: queryList(c, "tags")}
+
+	loc, err := queryLocation(c, "")
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
+	}
+	if opts.Counters, err = queryObjectIDs(c, "counter"); err != nil {
+		return err
+	}
+	if opts.From, err = queryTime(c, "from", loc); err != nil {
+		return err
+	}
+	if opts.To, err = queryTime(c, "to", loc); err != nil {
+		return err
+	}
+	if opts.MinNumber, err = queryInt(c, "min"); err != nil {
+		return err
+	}
+	if opts.MaxNumber, err = queryInt(c, "max"); err != nil {
+		return err
+	}
+
+	datas, err := db.Q.GetDatas(opts)
+	if errors.Is(err, queries.ErrInvalidOrdering) {
+		return apierror.InvalidParameter(fmt.Sprintf("%s '%s'", err.Error(), order))
+	}
+	if err != nil {
+		return err
 	}
 
 	/*
@@ -65,16 +115,16 @@ func GetDatas(c *fiber.Ctx) error {
 		paginatedItems := pagination.Paginate(items, config)
 	*/
 
+	response.SetMeta(c, "limit", opts.Limit)
+	response.SetMeta(c, "count", len(datas))
+
 	return c.JSON(datas)
 }
 
 func GetData(c *fiber.Ctx) error {
 	datas, err := db.Q.GetData(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(datas)
@@ -83,11 +133,16 @@ func GetData(c *fiber.Ctx) error {
 func DeleteData(c *fiber.Ctx) error {
 	deleted, err := db.Q.DeleteData(c.Params("id"))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": true,
-			"msg":   err.Error(),
-		})
+		return err
+	}
+	if !deleted {
+		return apierror.NotFound("data not found")
+	}
+
+	// v2 answers every delete with 204, v1 keeps its body
+	if response.IsEnveloped(c) {
+		return c.SendStatus(fiber.StatusNoContent)
 	}
 
-	return c.JSON(fiber.Map{"success": deleted})
+	return c.JSON(fiber.Map{"success": true})
 }
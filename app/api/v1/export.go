@@ -0,0 +1,243 @@
+package v1
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"main/app/models"
+	"main/app/pkg/apierror"
+	"main/app/pkg/db"
+	"main/app/queries"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var exportFormats = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"json":   fiber.MIMEApplicationJSONCharsetUTF8,
+	"ndjson": "application/x-ndjson",
+}
+
+var exportColumns = []string{"id", "counterId", "counterSlug", "counterName", "number", "tags", "createdAt", "updatedAt"}
+
+// exportFlush is the number of rows written between two flushes.
+const exportFlush = 100
+
+type exportRow struct {
+	ID          string   `json:"id"`
+	CounterID   string   `json:"counterId"`
+	CounterSlug string   `json:"counterSlug"`
+	CounterName string   `json:"counterName"`
+	Number      int      `json:"number"`
+	Tags        []string `json:"tags"`
+	CreatedAt   string   `json:"createdAt"`
+	UpdatedAt   string   `json:"updatedAt"`
+}
+
+// csvText keeps a spreadsheet from running a text cell as a formula, the
+// cells starting with one of =+-@, a tab or a carriage return are prefixed
+// with a quote.
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+// record is the CSV row, the names and tags are the only free text.
+func (row exportRow) record() []string {
+	return []string{row.ID, row.CounterID, row.CounterSlug, csvText(row.CounterName), strconv.Itoa(row.Number), csvText(strings.Join(row.Tags, ";")), row.CreatedAt, row.UpdatedAt}
+}
+
+// exportCounter is a counter of the export with the location its dates are
+// written in.
+type exportCounter struct {
+	counter  models.Counter
+	location *time.Location
+}
+
+func (ec exportCounter) row(data models.Data) exportRow {
+	tags := data.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return exportRow{
+		ID:          data.ID.Hex(),
+		CounterID:   ec.counter.ID.Hex(),
+		CounterSlug: ec.counter.Slug,
+		CounterName: ec.counter.Name,
+		Number:      data.Number,
+		Tags:        tags,
+		CreatedAt:   data.CreatedAt.Time().In(ec.location).Format(time.RFC3339),
+		UpdatedAt:   data.UpdatedAt.Time().In(ec.location).Format(time.RFC3339),
+	}
+}
+
+func queryExportFormat(c *fiber.Ctx) (string, error) {
+	format := strings.ToLower(strings.TrimSpace(c.Query("format", "csv")))
+	if _, ok := exportFormats[format]; !ok {
+		return "", apierror.InvalidParameter(fmt.Sprintf("'format': invalid format '%s', expected one of csv, json, ndjson", format))
+	}
+
+	return format, nil
+}
+
+// ExportCounter exports the data of a counter.
+func ExportCounter(c *fiber.Ctx) error {
+	counter, err := db.Q.GetCounter(c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	name := counter.Slug
+	if name == "" {
+		name = counter.ID.Hex()
+	}
+
+	return export(c, name, []models.Counter{counter})
+}
+
+// ExportCounters exports the data of the counters given by id or slug, or of
+// every counter when none is given.
+func ExportCounters(c *fiber.Ctx) error {
+	ids := queryList(c, "counters")
+	if len(ids) == 0 {
+		counters, err := db.Q.GetCounters()
+		if err != nil {
+			return err
+		}
+
+		return export(c, "counters", counters)
+	}
+
+	counters := make([]models.Counter, 0, len(ids))
+	seen := map[primitive.ObjectID]bool{}
+	for _, id := range ids {
+		counter, err := db.Q.GetCounter(id)
+		if err != nil {
+			return err
+		}
+		if !seen[counter.ID] {
+			seen[counter.ID] = true
+			counters = append(counters, counter)
+		}
+	}
+
+	return export(c, "counters", counters)
+}
+
+// export streams the data of the counters from the cursor, filtered and
+// dated as the other counter endpoints.
+func export(c *fiber.Ctx, name string, counters []models.Counter) error {
+	format, err := queryExportFormat(c)
+	if err != nil {
+		return err
+	}
+
+	exportCounters := make(map[primitive.ObjectID]exportCounter, len(counters))
+	counterQueries := make([]queries.CounterQuery, len(counters))
+	for i, counter := range counters {
+		opts, err := queryCounterOptions(c, counter)
+		if err != nil {
+			return err
+		}
+		exportCounters[counter.ID] = exportCounter{counter: counter, location: opts.Location}
+		counterQueries[i] = queries.CounterQuery{Counter: counter, Options: opts}
+	}
+
+	cursor, err := db.Q.ExportDatas(counterQueries)
+	if err != nil {
+		return err
+	}
+
+	// the context is released before the stream is written
+	method, path := c.Method(), c.Path()
+	c.Set(fiber.HeaderContentType, exportFormats[format])
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cursor.Close(context.Background())
+		if err := writeExport(w, format, cursor, exportCounters); err != nil {
+			// the status is already sent, the export is cut short
+			log.Printf("%s %s: %v", method, path, err)
+		}
+	})
+
+	return nil
+}
+
+func writeExport(w *bufio.Writer, format string, cursor *mongo.Cursor, counters map[primitive.ObjectID]exportCounter) error {
+	csvWriter := csv.NewWriter(w)
+	switch format {
+	case "csv":
+		if err := csvWriter.Write(exportColumns); err != nil {
+			return err
+		}
+	case "json":
+		if err := w.WriteByte('['); err != nil {
+			return err
+		}
+	}
+
+	rows := 0
+	for cursor.Next(context.Background()) {
+		var data models.Data
+		if err := cursor.Decode(&data); err != nil {
+			return err
+		}
+		row := counters[data.Counter].row(data)
+
+		switch format {
+		case "csv":
+			if err := csvWriter.Write(row.record()); err != nil {
+				return err
+			}
+		case "json", "ndjson":
+			b, err := json.Marshal(row)
+			if err != nil {
+				return err
+			}
+			if format == "json" && rows > 0 {
+				b = append([]byte{','}, b...)
+			}
+			if format == "ndjson" {
+				b = append(b, '\n')
+			}
+			if _, err := w.Write(b); err != nil {
+				return err
+			}
+		}
+
+		rows++
+		if rows%exportFlush == 0 {
+			csvWriter.Flush()
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if format == "json" {
+		if err := w.WriteByte(']'); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
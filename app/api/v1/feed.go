@@ -0,0 +1,56 @@
+package v1
+
+import (
+	"errors"
+	"main/app/pkg/apierror"
+	"main/app/pkg/db"
+	"main/app/pkg/response"
+	"main/app/pkg/utils"
+	"main/app/queries"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func GetFeed(c *fiber.Ctx) error {
+	opts := queries.FeedOptions{Cursor: strings.TrimSpace(c.Query("cursor", "")), Limit: 50}
+
+	var err error
+	if opts.Location, err = queryLocation(c, ""); err != nil {
+		return err
+	}
+	if opts.Counters, err = queryObjectIDs(c, "counter"); err != nil {
+		return err
+	}
+	if opts.Day, err = queryTime(c, "day", opts.Location); err != nil {
+		return err
+	}
+	if opts.Day != nil {
+		day := utils.StartOfDay(opts.Day.In(opts.Location))
+		opts.Day = &day
+	}
+
+	limit, err := queryInt(c, "limit")
+	if err != nil {
+		return err
+	}
+	if limit != nil {
+		if *limit < 1 || *limit > 200 {
+			return apierror.InvalidParameter("'limit': expected a number between 1 and 200")
+		}
+		opts.Limit = int64(*limit)
+	}
+
+	feed, err := db.Q.GetFeed(opts)
+	if errors.Is(err, queries.ErrInvalidCursor) {
+		return apierror.InvalidParameter(err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	response.SetMeta(c, "limit", opts.Limit)
+	response.SetMeta(c, "nextCursor", feed.NextCursor)
+
+	return c.JSON(feed)
+}
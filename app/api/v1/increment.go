@@ -0,0 +1,128 @@
+package v1
+
+import (
+	"errors"
+	"main/app/models"
+	"main/app/pkg/apierror"
+	. "main/app/pkg/configs"
+	"main/app/pkg/db"
+	"main/app/pkg/events"
+	"main/app/queries"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// incrementSkew is how far in the future an 'at' may be, for the clocks of
+// the clients running ahead.
+const incrementSkew = time.Minute
+
+type incrementResponse struct {
+	Entry models.Data          `json:"entry"`
+	Stats queries.CounterStats `json:"stats"`
+}
+
+// IncrementCounter creates an entry from the optional by (1 by default), at
+// and tags, read from the query or a JSON body so that a bare POST works.
+func IncrementCounter(c *fiber.Ctx) error {
+	counter, err := db.Q.GetCounter(c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	opts, err := queryCounterOptions(c, counter)
+	if err != nil {
+		return err
+	}
+
+	var body struct {
+		By   *int       `json:"by"`
+		At   *time.Time `json:"at"`
+		Tags []string   `json:"tags"`
+	}
+	if len(c.Body()) != 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return apierror.BadRequest(apierror.CodeInvalidBody, "cannot parse the request body")
+		}
+	}
+	if body.By == nil {
+		if body.By, err = queryInt(c, "by"); err != nil {
+			return err
+		}
+	}
+	if body.At == nil {
+		if body.At, err = queryTime(c, "at", opts.Location); err != nil {
+			return err
+		}
+	}
+	if body.At != nil && body.At.After(time.Now().Add(incrementSkew)) {
+		return apierror.InvalidParameter("'at' may not be in the future")
+	}
+	if body.Tags == nil {
+		body.Tags = queryList(c, "tags")
+	}
+
+	data := models.Data{Number: 1, Counter: counter.ID, Tags: body.Tags}
+	if body.By != nil {
+		data.Number = *body.By
+	}
+	data.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+	if body.At != nil {
+		data.CreatedAt = primitive.NewDateTimeFromTime(*body.At)
+	}
+	data.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+
+	fields, err := validateData(data)
+	if err != nil {
+		return err
+	}
+	if fields != nil {
+		return apierror.Validation(fields)
+	}
+
+	dbdata, err := db.Q.CreateData(data)
+	if err != nil {
+		return err
+	}
+	events.Entries.Publish(dbdata)
+
+	stats, err := db.Q.GetCounterStats(counter, opts)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(incrementResponse{Entry: dbdata, Stats: stats})
+}
+
+// UndoCounter deletes the last entry written for the counter within the undo
+// window.
+func UndoCounter(c *fiber.Ctx) error {
+	counter, err := db.Q.GetCounter(c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	opts, err := queryCounterOptions(c, counter)
+	if err != nil {
+		return err
+	}
+
+	since := time.Now().Add(-Configs.Duration("undo.window"))
+	data, err := db.Q.UndoLastData(counter, since)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return apierror.NotFound("no entry to undo within the undo window")
+	}
+	if err != nil {
+		return err
+	}
+	events.Entries.PublishDeleted(data)
+
+	stats, err := db.Q.GetCounterStats(counter, opts)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(incrementResponse{Entry: data, Stats: stats})
+}
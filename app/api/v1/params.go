@@ -0,0 +1,154 @@
+package v1
+
+import (
+	"fmt"
+	"main/app/models"
+	"main/app/pkg/apierror"
+	"main/app/pkg/response"
+	"main/app/pkg/utils"
+	"main/app/queries"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func queryList(c *fiber.Ctx, key string) []string {
+	var values []string
+	for _, value := range strings.Split(c.Query(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+func queryObjectIDs(c *fiber.Ctx, key string) ([]primitive.ObjectID, error) {
+	var ids []primitive.ObjectID
+	for _, value := range queryList(c, key) {
+		id, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			return nil, apierror.InvalidParameter(fmt.Sprintf("'%s': invalid id '%s'", key, value))
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func queryLocation(c *fiber.Ctx, fallback string) (*time.Location, error) {
+	name := strings.TrimSpace(c.Query("tz", ""))
+	if name == "" {
+		name = fallback
+	}
+
+	loc, err := utils.LoadLocation(name)
+	if err != nil {
+		return nil, apierror.InvalidParameter(fmt.Sprintf("'tz': invalid timezone '%s'", name))
+	}
+
+	return loc, nil
+}
+
+// queryTime parses an RFC3339 date, or a plain date which is taken as
+// midnight in loc.
+func queryTime(c *fiber.Ctx, key string, loc *time.Location) (*time.Time, error) {
+	value := strings.TrimSpace(c.Query(key, ""))
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return &t, nil
+		}
+	}
+
+	return nil, apierror.InvalidParameter(fmt.Sprintf("'%s': invalid date '%s'", key, value))
+}
+
+func queryInt(c *fiber.Ctx, key string) (*int, error) {
+	value := strings.TrimSpace(c.Query(key, ""))
+	if value == "" {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, apierror.InvalidParameter(fmt.Sprintf("'%s': invalid number '%s'", key, value))
+	}
+
+	return &n, nil
+}
+
+func queryCounterOptions(c *fiber.Ctx, counter models.Counter) (queries.CounterOptions, error) {
+	opts := queries.CounterOptions{Global: utils.StringToBool(c.Query("global", ""))}
+
+	loc, err := queryLocation(c, counter.Timezone)
+	if err != nil {
+		return opts, err
+	}
+	opts.Location = loc
+
+	if preset := strings.TrimSpace(c.Query("range", "")); preset != "" {
+		from, to, ok := utils.DateRangePreset(preset, time.Now().In(loc))
+		if !ok {
+			return opts, apierror.InvalidParameter(fmt.Sprintf("'range': invalid preset '%s', expected one of %s", preset, strings.Join(utils.DateRangePresets, ", ")))
+		}
+		opts.From, opts.To = &from, &to
+	}
+
+	from, err := queryTime(c, "from", loc)
+	if err != nil {
+		return opts, err
+	}
+	if from != nil {
+		opts.From = from
+	}
+
+	to, err := queryTime(c, "to", loc)
+	if err != nil {
+		return opts, err
+	}
+	if to != nil {
+		opts.To = to
+	}
+
+	if opts.From != nil && opts.To != nil && !opts.From.Before(*opts.To) {
+		return opts, apierror.InvalidParameter("'from' must be before 'to'")
+	}
+
+	response.SetMeta(c, "range", fiber.Map{"from": opts.From, "to": opts.To, "tz": loc.String(), "global": opts.Global})
+
+	return opts, nil
+}
+
+func queryFloat(c *fiber.Ctx, key string) (*float64, error) {
+	value := strings.TrimSpace(c.Query(key, ""))
+	if value == "" {
+		return nil, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, apierror.InvalidParameter(fmt.Sprintf("'%s': invalid number '%s'", key, value))
+	}
+
+	return &f, nil
+}
+
+func queryFloats(c *fiber.Ctx, key string) ([]float64, error) {
+	var values []float64
+	for _, value := range queryList(c, key) {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, apierror.InvalidParameter(fmt.Sprintf("'%s': invalid number '%s'", key, value))
+		}
+		values = append(values, f)
+	}
+
+	return values, nil
+}
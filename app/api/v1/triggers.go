@@ -0,0 +1,210 @@
+package v1
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"main/app/models"
+	"main/app/pkg/apierror"
+	. "main/app/pkg/configs"
+	"main/app/pkg/db"
+	"main/app/pkg/events"
+	"main/app/pkg/validation"
+	"main/app/queries"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//go:embed trigger.html
+var triggerPageSource string
+
+var triggerPage = template.Must(template.New("trigger").Parse(triggerPageSource))
+
+type createdTrigger struct {
+	models.Trigger
+	// Token is only known on creation.
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func CreateTrigger(c *fiber.Ctx) error {
+	counter, err := db.Q.GetCounter(c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	trigger := models.Trigger{Number: 1}
+	if err := c.BodyParser(&trigger); err != nil {
+		return apierror.BadRequest(apierror.CodeInvalidBody, "cannot parse the request body")
+	}
+
+	if fields := validation.Struct(trigger); fields != nil {
+		return apierror.Validation(fields)
+	}
+	if fields := validation.DataBounds(models.Data{Number: trigger.Number}, counter); fields != nil {
+		return apierror.Validation(fields)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	trigger.ID = primitive.NilObjectID
+	trigger.Counter = counter.ID
+	trigger.TokenHash = hashToken(token)
+	trigger.TokenHint = token[:6]
+	trigger.LastUsedAt = nil
+	trigger.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+
+	dbdata, err := db.Q.Triggers.CreateTrigger(trigger)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(createdTrigger{Trigger: dbdata, Token: token, URL: c.BaseURL() + "/t/" + token})
+}
+
+func GetTriggers(c *fiber.Ctx) error {
+	counter, err := db.Q.GetCounter(c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	triggers, err := db.Q.Triggers.GetTriggers(counter)
+	if err != nil {
+		return err
+	}
+
+	if len(triggers) == 0 {
+		return c.JSON([]interface{}{})
+	}
+
+	return c.JSON(triggers)
+}
+
+func DeleteTrigger(c *fiber.Ctx) error {
+	counter, err := db.Q.GetCounter(c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	deleted, err := db.Q.Triggers.DeleteTrigger(counter, c.Params("triggerId"))
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return apierror.NotFound("trigger not found")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+type triggerPageData struct {
+	Title   string
+	Message string
+	Color   string
+}
+
+func renderTrigger(c *fiber.Ctx, status int, page triggerPageData) error {
+	if page.Color == "" {
+		page.Color = "#333"
+	}
+
+	var html bytes.Buffer
+	if err := triggerPage.Execute(&html, page); err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Type("html")
+	return c.Status(status).Send(html.Bytes())
+}
+
+// TriggerLimitReached answers the clients over the trigger rate limit.
+func TriggerLimitReached(c *fiber.Ctx) error {
+	return renderTrigger(c, fiber.StatusTooManyRequests, triggerPageData{Title: "Slow down", Message: "Too many scans, try again in a minute."})
+}
+
+// UseTrigger creates the entry of the trigger of the token, then redirects
+// or shows a confirmation page. The scans within the cooldown of the trigger
+// are not counted, the ones rejected by the bounds of the counter do not
+// start the cooldown.
+func UseTrigger(c *fiber.Ctx) error {
+	unknown := triggerPageData{Title: "Unknown trigger", Message: "This trigger does not exist or was revoked."}
+	hash := hashToken(c.Params("token"))
+
+	trigger, err := db.Q.Triggers.GetTrigger(hash)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return renderTrigger(c, fiber.StatusNotFound, unknown)
+	}
+	if err != nil {
+		return err
+	}
+
+	counter, err := db.Q.GetCounter(trigger.Counter.Hex())
+	if err != nil {
+		return err
+	}
+
+	data := models.Data{
+		Number:    trigger.Number,
+		Counter:   counter.ID,
+		Tags:      trigger.Tags,
+		CreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+		UpdatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	}
+	if fields := validation.DataBounds(data, counter); fields != nil {
+		return renderTrigger(c, fiber.StatusUnprocessableEntity, triggerPageData{Title: "Not counted", Message: fields[0].Msg})
+	}
+
+	cooldown := Configs.Duration("triggers.cooldown")
+	_, err = db.Q.Triggers.UseTrigger(hash, cooldown)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return renderTrigger(c, fiber.StatusNotFound, unknown)
+	}
+	if errors.Is(err, queries.ErrTriggerCooldown) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(cooldown.Seconds())))
+		return renderTrigger(c, fiber.StatusTooManyRequests, triggerPageData{Title: "Already counted", Message: "This trigger was just used, the scan was ignored."})
+	}
+	if err != nil {
+		return err
+	}
+
+	dbdata, err := db.Q.CreateData(data)
+	if err != nil {
+		return err
+	}
+	events.Entries.Publish(dbdata)
+
+	if trigger.Redirect != "" {
+		return c.Redirect(trigger.Redirect, fiber.StatusSeeOther)
+	}
+
+	stats, err := db.Q.GetCounterStats(counter, queries.CounterOptions{})
+	if err != nil {
+		return err
+	}
+
+	return renderTrigger(c, fiber.StatusOK, triggerPageData{
+		Title:   fmt.Sprintf("%+d", trigger.Number),
+		Message: fmt.Sprintf("%s is now at %d.", counter.Name, stats.Total),
+		Color:   counter.Color,
+	})
+}
@@ -1,11 +1,25 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type Counter struct {
 	ID        primitive.ObjectID  `json:"id,omitempty"        bson:"_id,omitempty"`
-	Name      string              `json:"name,omitempty"      bson:"name"                validate:"required"`
+	Name      string              `json:"name,omitempty"      bson:"name"                validate:"required,max=100"`
+	Slug      string              `json:"slug,omitempty"      bson:"slug,omitempty"      validate:"omitempty,slug"`
 	SoftReset *primitive.DateTime `json:"softReset,omitempty" bson:"softReset,omitempty"`
+	Timezone  string              `json:"timezone,omitempty"  bson:"timezone,omitempty"  validate:"omitempty,timezone"`
+	Color     string              `json:"color,omitempty"     bson:"color,omitempty"     validate:"omitempty,color"`
+	Min       *int                `json:"min,omitempty"       bson:"min,omitempty"`
+	Max       *int                `json:"max,omitempty"       bson:"max,omitempty"`
 	CreatedAt primitive.DateTime  `json:"createdAt,omitempty" bson:"createdAt"`
 	UpdatedAt primitive.DateTime  `json:"updatedAt,omitempty" bson:"updatedAt"`
 }
+
+// ETag changes on every edit of the counter.
+func (counter Counter) ETag() string {
+	return fmt.Sprintf(`"%s-%d"`, counter.ID.Hex(), int64(counter.UpdatedAt))
+}
@@ -0,0 +1,18 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Trigger creates an entry of its counter when its URL is opened, only the
+// hash of its token is stored.
+type Trigger struct {
+	ID         primitive.ObjectID  `json:"id,omitempty"         bson:"_id,omitempty"`
+	Counter    primitive.ObjectID  `json:"counterRef"           bson:"counter_ref"`
+	Name       string              `json:"name,omitempty"       bson:"name,omitempty"       validate:"max=100"`
+	Number     int                 `json:"number"               bson:"number"               validate:"required"`
+	Tags       []string            `json:"tags,omitempty"       bson:"tags,omitempty"`
+	Redirect   string              `json:"redirect,omitempty"   bson:"redirect,omitempty"   validate:"omitempty,http_url"`
+	TokenHash  string              `json:"-"                    bson:"tokenHash"`
+	TokenHint  string              `json:"tokenHint"            bson:"tokenHint"`
+	LastUsedAt *primitive.DateTime `json:"lastUsedAt,omitempty" bson:"lastUsedAt,omitempty"`
+	CreatedAt  primitive.DateTime  `json:"createdAt,omitempty"  bson:"createdAt"`
+}
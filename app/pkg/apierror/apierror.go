@@ -0,0 +1,188 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"main/app/pkg/response"
+	"main/app/pkg/validation"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	CodeBadRequest            = "bad_request"
+	CodeInvalidBody           = "invalid_body"
+	CodeInvalidParameter      = "invalid_parameter"
+	CodeInvalidID             = "invalid_id"
+	CodeInvalidIdempotencyKey = "invalid_idempotency_key"
+	CodeNotFound              = "not_found"
+	CodeConflict              = "conflict"
+	CodePreconditionFailed    = "precondition_failed"
+	CodePreconditionRequired  = "precondition_required"
+	CodeValidation            = "validation_failed"
+	CodeTooManyRequests       = "too_many_requests"
+	CodeUnavailable           = "service_unavailable"
+	CodeInternal              = "internal_error"
+)
+
+type Error struct {
+	Status int                     `json:"-"`
+	Code   string                  `json:"code"`
+	Msg    string                  `json:"msg"`
+	Fields []validation.FieldError `json:"fields,omitempty"`
+	// Current is the current representation of a resource that failed a
+	// precondition.
+	Current any `json:"current,omitempty"`
+	// Err is the cause, it is logged but never sent to the client.
+	Err error `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Msg + ": " + e.Err.Error()
+	}
+
+	return e.Msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func New(status int, code string, msg string) *Error {
+	return &Error{Status: status, Code: code, Msg: msg}
+}
+
+func BadRequest(code string, msg string) *Error {
+	return New(fiber.StatusBadRequest, code, msg)
+}
+
+func InvalidParameter(msg string) *Error {
+	return New(fiber.StatusBadRequest, CodeInvalidParameter, msg)
+}
+
+func NotFound(msg string) *Error {
+	return New(fiber.StatusNotFound, CodeNotFound, msg)
+}
+
+func Conflict(msg string) *Error {
+	return New(fiber.StatusConflict, CodeConflict, msg)
+}
+
+func Validation(fields []validation.FieldError) *Error {
+	err := New(fiber.StatusUnprocessableEntity, CodeValidation, "validation failed")
+	err.Fields = fields
+
+	return err
+}
+
+func PreconditionFailed(current any) *Error {
+	err := New(fiber.StatusPreconditionFailed, CodePreconditionFailed, "the resource was modified")
+	err.Current = current
+
+	return err
+}
+
+func PreconditionRequired(msg string) *Error {
+	return New(fiber.StatusPreconditionRequired, CodePreconditionRequired, msg)
+}
+
+// From maps err to an API error, unknown errors are internal ones.
+func From(err error) *Error {
+	var apiError *Error
+	if errors.As(err, &apiError) {
+		return apiError
+	}
+
+	var fiberError *fiber.Error
+	if errors.As(err, &fiberError) {
+		return New(fiberError.Code, codeFromStatus(fiberError.Code), fiberError.Message)
+	}
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return &Error{Status: fiber.StatusNotFound, Code: CodeNotFound, Msg: "resource not found", Err: err}
+	case errors.Is(err, primitive.ErrInvalidHex):
+		return &Error{Status: fiber.StatusBadRequest, Code: CodeInvalidID, Msg: "invalid id", Err: err}
+	case mongo.IsDuplicateKeyError(err):
+		return &Error{Status: fiber.StatusConflict, Code: CodeConflict, Msg: "resource already exists", Err: err}
+	case mongo.IsTimeout(err), mongo.IsNetworkError(err), errors.Is(err, mongo.ErrClientDisconnected):
+		return &Error{Status: fiber.StatusServiceUnavailable, Code: CodeUnavailable, Msg: "database unavailable", Err: err}
+	}
+
+	return &Error{Status: fiber.StatusInternalServerError, Code: CodeInternal, Msg: "internal server error", Err: err}
+}
+
+func codeFromStatus(status int) string {
+	switch status {
+	case fiber.StatusBadRequest:
+		return CodeBadRequest
+	case fiber.StatusNotFound:
+		return CodeNotFound
+	case fiber.StatusConflict:
+		return CodeConflict
+	case fiber.StatusPreconditionFailed:
+		return CodePreconditionFailed
+	case fiber.StatusUnprocessableEntity:
+		return CodeValidation
+	case fiber.StatusTooManyRequests:
+		return CodeTooManyRequests
+	case fiber.StatusServiceUnavailable:
+		return CodeUnavailable
+	case fiber.StatusInternalServerError:
+		return CodeInternal
+	}
+
+	return strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+}
+
+// Handler is the fiber ErrorHandler, it writes every error with the same
+// shape and logs the causes of server errors.
+func Handler(c *fiber.Ctx, err error) error {
+	apiError := From(err)
+	if apiError.Status >= fiber.StatusInternalServerError {
+		log.Printf("%s %s: %v", c.Method(), c.Path(), err)
+	}
+
+	if response.IsEnveloped(c) {
+		return c.Status(apiError.Status).JSON(apiError.envelope(c))
+	}
+
+	return c.Status(apiError.Status).JSON(struct {
+		IsError bool `json:"error"`
+		*Error
+	}{true, apiError})
+}
+
+type envelopeError struct {
+	Code  string `json:"code"`
+	Msg   string `json:"msg"`
+	Field string `json:"field,omitempty"`
+	Rule  string `json:"rule,omitempty"`
+}
+
+// envelope lists an error for each invalid field, the current resource of a
+// failed precondition is the data.
+func (e *Error) envelope(c *fiber.Ctx) response.Envelope {
+	var errors []any
+	for _, field := range e.Fields {
+		errors = append(errors, envelopeError{Code: e.Code, Msg: field.Msg, Field: field.Field, Rule: field.Rule})
+	}
+	if len(errors) == 0 {
+		errors = append(errors, envelopeError{Code: e.Code, Msg: e.Msg})
+	}
+
+	data := json.RawMessage("null")
+	if e.Current != nil {
+		if current, err := json.Marshal(e.Current); err == nil {
+			data = current
+		}
+	}
+
+	return response.Envelope{Data: data, Meta: response.Meta(c), Errors: errors}
+}
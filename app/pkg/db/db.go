@@ -14,6 +14,9 @@ import (
 type Queries struct {
 	*queries.CounterQueries
 	*queries.DataQueries
+
+	Idempotency *queries.IdempotencyQueries
+	Triggers    *queries.TriggerQueries
 }
 
 var Q Queries
@@ -43,6 +46,18 @@ func InitDB() {
 		Q = Queries{
 			CounterQueries: &queries.CounterQueries{Collection: instance.Collection("counters")},
 			DataQueries:    &queries.DataQueries{Collection: instance.Collection("datas")},
+			Idempotency:    &queries.IdempotencyQueries{Collection: instance.Collection("idempotency_keys")},
+			Triggers:       &queries.TriggerQueries{Collection: instance.Collection("triggers")},
+		}
+
+		if err := Q.CounterQueries.EnsureIndexes(); err != nil {
+			panic(err)
+		}
+		if err := Q.Idempotency.EnsureIndexes(); err != nil {
+			panic(err)
+		}
+		if err := Q.Triggers.EnsureIndexes(); err != nil {
+			panic(err)
 		}
 	})
 }
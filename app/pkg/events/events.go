@@ -0,0 +1,87 @@
+package events
+
+import (
+	"main/app/models"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// subscriberBuffer is the number of events a slow subscriber may lag behind
+// before it misses some.
+const subscriberBuffer = 16
+
+// Event is an entry created, or deleted by an undo.
+type Event struct {
+	Entry   models.Data
+	Deleted bool
+}
+
+type subscriber struct {
+	counters map[primitive.ObjectID]bool
+	events   chan Event
+}
+
+// Broker delivers the created and deleted entries to the subscribers of this
+// process.
+type Broker struct {
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+}
+
+// Entries is the broker of the entries.
+var Entries = &Broker{subscribers: map[*subscriber]struct{}{}}
+
+// Subscribe receives the events of the counters, or of every counter without
+// any. The channel is closed by the returned cancel function.
+func (b *Broker) Subscribe(counters []primitive.ObjectID) (<-chan Event, func()) {
+	sub := &subscriber{events: make(chan Event, subscriberBuffer)}
+	if len(counters) != 0 {
+		sub.counters = map[primitive.ObjectID]bool{}
+		for _, id := range counters {
+			sub.counters[id] = true
+		}
+	}
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return sub.events, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, sub)
+			b.mu.Unlock()
+			close(sub.events)
+		})
+	}
+}
+
+// Publish sends a created entry.
+func (b *Broker) Publish(data models.Data) {
+	b.publish(Event{Entry: data})
+}
+
+// PublishDeleted sends an entry deleted by an undo.
+func (b *Broker) PublishDeleted(data models.Data) {
+	b.publish(Event{Entry: data, Deleted: true})
+}
+
+// publish never blocks, the event is dropped for the subscribers whose
+// buffer is full.
+func (b *Broker) publish(event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subscribers {
+		if sub.counters != nil && !sub.counters[event.Entry.Counter] {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+}
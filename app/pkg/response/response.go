@@ -0,0 +1,66 @@
+package response
+
+import (
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	localsEnvelope = "response_envelope"
+	localsMeta     = "response_meta"
+)
+
+type Envelope struct {
+	Data   json.RawMessage `json:"data"`
+	Meta   map[string]any  `json:"meta"`
+	Errors []any           `json:"errors"`
+}
+
+// SetMeta adds metadata to the response, it is only sent by the enveloped
+// versions of the API.
+func SetMeta(c *fiber.Ctx, key string, value any) {
+	meta, _ := c.Locals(localsMeta).(map[string]any)
+	if meta == nil {
+		meta = map[string]any{}
+		c.Locals(localsMeta, meta)
+	}
+
+	meta[key] = value
+}
+
+func Meta(c *fiber.Ctx) map[string]any {
+	meta, _ := c.Locals(localsMeta).(map[string]any)
+	if meta == nil {
+		return map[string]any{}
+	}
+
+	return meta
+}
+
+func IsEnveloped(c *fiber.Ctx) bool {
+	return c.Locals(localsEnvelope) != nil
+}
+
+// Enveloped wraps the successful JSON responses in an Envelope, the
+// responses without content keep their 204 status.
+// The errors are wrapped by the error handler.
+func Enveloped(c *fiber.Ctx) error {
+	c.Locals(localsEnvelope, true)
+
+	if err := c.Next(); err != nil {
+		return err
+	}
+
+	// the streamed responses are sent as they are
+	status := c.Response().StatusCode()
+	if status < fiber.StatusOK || status >= fiber.StatusMultipleChoices || status == fiber.StatusNoContent || c.Response().IsBodyStream() {
+		return nil
+	}
+	if string(c.Response().Header.ContentType()) != fiber.MIMEApplicationJSON {
+		return nil
+	}
+
+	data := append(json.RawMessage{}, c.Response().Body()...)
+	return c.Status(fiber.StatusOK).JSON(Envelope{Data: data, Meta: Meta(c), Errors: []any{}})
+}
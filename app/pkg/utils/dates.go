@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"errors"
+	"time"
+)
+
+var DateRangePresets = []string{"today", "this-week", "last-30d", "ytd"}
+
+var ErrInvalidTimezone = errors.New("invalid timezone")
+
+// LoadLocation resolves an IANA timezone name, an empty name is UTC.
+// "Local" is refused since it depends on the server and is unknown to mongo.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+
+	return loc, nil
+}
+
+func StartOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func DateRangePreset(name string, now time.Time) (time.Time, time.Time, bool) {
+	today := StartOfDay(now)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	switch name {
+	case "today":
+		return today, tomorrow, true
+	case "this-week":
+		// weeks start on monday
+		offset := (int(today.Weekday()) + 6) % 7
+		return today.AddDate(0, 0, -offset), tomorrow, true
+	case "last-30d":
+		return today.AddDate(0, 0, -29), tomorrow, true
+	case "ytd":
+		return time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location()), tomorrow, true
+	}
+
+	return time.Time{}, time.Time{}, false
+}
+
+// DaysBetween counts the calendar days, in the location of start, touched by
+// the half-open interval [start, end).
+func DaysBetween(start time.Time, end time.Time) int {
+	if !end.After(start) {
+		return 0
+	}
+
+	first := StartOfDay(start)
+	last := StartOfDay(end.In(start.Location()).Add(-time.Nanosecond))
+
+	// compare civil dates so DST changes don't shift the count
+	firstDate := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, time.UTC)
+	lastDate := time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, time.UTC)
+
+	return int(lastDate.Sub(firstDate).Hours()/24) + 1
+}
+
+var Intervals = []string{"hour", "day", "week", "month", "quarter", "year"}
+
+// TruncateInterval returns the start of the interval containing t, in the
+// location of t. Weeks start on monday.
+func TruncateInterval(t time.Time, interval string) time.Time {
+	switch interval {
+	case "hour":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	case "week":
+		day := StartOfDay(t)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case "quarter":
+		return time.Date(t.Year(), t.Month()-(t.Month()-1)%3, 1, 0, 0, 0, 0, t.Location())
+	case "year":
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	}
+
+	return StartOfDay(t)
+}
+
+func AddInterval(t time.Time, interval string, n int) time.Time {
+	switch interval {
+	case "hour":
+		return t.Add(time.Duration(n) * time.Hour)
+	case "week":
+		return t.AddDate(0, 0, 7*n)
+	case "month":
+		return t.AddDate(0, n, 0)
+	case "quarter":
+		return t.AddDate(0, 3*n, 0)
+	case "year":
+		return t.AddDate(n, 0, 0)
+	}
+
+	return t.AddDate(0, 0, n)
+}
+
+// FormatInterval formats the start of a bucket as an ISO date, with the time
+// only when the interval is shorter than a day.
+func FormatInterval(t time.Time, interval string) string {
+	if interval == "hour" {
+		return t.Format(time.RFC3339)
+	}
+
+	return t.Format(time.DateOnly)
+}
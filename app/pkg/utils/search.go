@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+const maxSlugLength = 100
+
+var (
+	slugPattern  = regexp.MustCompile("^[a-z0-9]+(-[a-z0-9]+)*$")
+	nonAlnumRuns = regexp.MustCompile("[^a-z0-9]+")
+)
+
+// Fold lowercases str and removes its diacritics, "Café" becomes "cafe".
+func Fold(str string) string {
+	folder := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(folder, str)
+	if err != nil {
+		folded = str
+	}
+
+	return strings.ToLower(folded)
+}
+
+// Slugify turns a name into a URL friendly slug, empty when the name has no
+// letter or digit.
+func Slugify(name string) string {
+	slug := strings.Trim(nonAlnumRuns.ReplaceAllString(Fold(name), "-"), "-")
+	if len(slug) > maxSlugLength {
+		slug = strings.TrimRight(slug[:maxSlugLength], "-")
+	}
+
+	return slug
+}
+
+func IsSlug(str string) bool {
+	return len(str) <= maxSlugLength && slugPattern.MatchString(str)
+}
+
+// Levenshtein is the edit distance between a and b.
+func Levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+// similarity is 1 for equal words and decreases with their edit distance.
+func similarity(a string, b string) float64 {
+	longest := max(len([]rune(a)), len([]rune(b)))
+	if longest == 0 {
+		return 1
+	}
+
+	return 1 - float64(Levenshtein(a, b))/float64(longest)
+}
+
+// compact removes the separators, so "push-ups" matches "pushups".
+func compact(str string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, str)
+}
+
+// minWordSimilarity tolerates about one typo in a word of four letters.
+const minWordSimilarity = 0.7
+
+// FuzzyScore ranks how well text matches query, from 1 for the same text to
+// 0 for no match. Exact, prefix and substring matches rank first, then the
+// texts whose words are all close to a word of the query.
+func FuzzyScore(query string, text string) float64 {
+	query, text = strings.Join(strings.Fields(Fold(query)), " "), Fold(text)
+	if query == "" {
+		return 0
+	}
+
+	switch {
+	case text == query:
+		return 1
+	case strings.HasPrefix(text, query):
+		return 0.9
+	case strings.Contains(" "+text, " "+query):
+		return 0.8
+	case strings.Contains(text, query), strings.Contains(compact(text), compact(query)):
+		return 0.7
+	}
+
+	words := strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	var total float64
+	for _, term := range strings.Fields(query) {
+		best := 0.0
+		for _, word := range words {
+			score := similarity(term, word)
+			if strings.HasPrefix(word, term) {
+				score = 1
+			} else if prefix := []rune(word); len(prefix) > len([]rune(term)) {
+				// a typo in the beginning of a longer word
+				score = max(score, similarity(term, string(prefix[:len([]rune(term))])))
+			}
+			best = max(best, score)
+		}
+		if best < minWordSimilarity {
+			return 0
+		}
+		total += best
+	}
+
+	return 0.6 * total / float64(len(strings.Fields(query)))
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		str  string
+		want string
+	}{
+		{"Café", "cafe"},
+		{"ÉLÈVE Noël", "eleve noel"},
+		{"Straße", "straße"},
+		{"push-ups", "push-ups"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if got := Fold(test.str); got != test.want {
+			t.Errorf("Fold(%q) = %q, want %q", test.str, got, test.want)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Push-ups", "push-ups"},
+		{"  Morning   run!  ", "morning-run"},
+		{"Café crème", "cafe-creme"},
+		{"Ça va? Ça va.", "ca-va-ca-va"},
+		{"!!!", ""},
+		{"日本", ""},
+		// the ObjectID-like slugs are rejected by the callers
+		{"507F1F77BCF86CD799439011", "507f1f77bcf86cd799439011"},
+		{strings.Repeat("a", 120), strings.Repeat("a", 100)},
+		// the truncation does not leave a trailing dash
+		{strings.Repeat("abcd ", 30), strings.TrimSuffix(strings.Repeat("abcd-", 20), "-")},
+	}
+
+	for _, test := range tests {
+		if got := Slugify(test.name); got != test.want {
+			t.Errorf("Slugify(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestIsSlug(t *testing.T) {
+	tests := []struct {
+		str  string
+		want bool
+	}{
+		{"push-ups", true},
+		{"a1", true},
+		{"507f1f77bcf86cd799439011", true},
+		{"Push-ups", false},
+		{"push--ups", false},
+		{"-push", false},
+		{"push-", false},
+		{"push ups", false},
+		{"", false},
+		{strings.Repeat("a", 100), true},
+		{strings.Repeat("a", 101), false},
+	}
+
+	for _, test := range tests {
+		if got := IsSlug(test.str); got != test.want {
+			t.Errorf("IsSlug(%q) = %t, want %t", test.str, got, test.want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "abc", 0},
+		{"kitten", "sitting", 3},
+		{"push", "puhs", 2},
+		// runes, not bytes
+		{"café", "cafe", 1},
+	}
+
+	for _, test := range tests {
+		if got := Levenshtein(test.a, test.b); got != test.want {
+			t.Errorf("Levenshtein(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+		if got := Levenshtein(test.b, test.a); got != test.want {
+			t.Errorf("Levenshtein(%q, %q) = %d, want %d", test.b, test.a, got, test.want)
+		}
+	}
+}
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		query string
+		text  string
+		want  float64
+	}{
+		{"push", "Push", 1},
+		{"  PUSH ", "push", 1},
+		{"cafe", "Café", 1},
+		{"push", "Push-ups", 0.9},
+		{"push", "Morning push", 0.8},
+		{"push", "Bench pushdown", 0.8},
+		{"push", "repushed", 0.7},
+		{"pushups", "Push-ups", 0.7},
+		{"push", "Squats", 0},
+		{"push", "Puhs ups", 0},
+		{"", "Push", 0},
+		{"   ", "Push", 0},
+	}
+
+	for _, test := range tests {
+		if got := FuzzyScore(test.query, test.text); got != test.want {
+			t.Errorf("FuzzyScore(%q, %q) = %v, want %v", test.query, test.text, got, test.want)
+		}
+	}
+}
+
+func TestFuzzyScoreTypos(t *testing.T) {
+	tests := []struct {
+		query string
+		text  string
+	}{
+		{"pusj", "Push-ups"},
+		{"vist", "Café visits"},
+		{"morming run", "Morning run"},
+	}
+
+	for _, test := range tests {
+		if got := FuzzyScore(test.query, test.text); got <= 0 || got >= 0.7 {
+			t.Errorf("FuzzyScore(%q, %q) = %v, want a typo match in (0, 0.7)", test.query, test.text, got)
+		}
+	}
+}
+
+func TestFuzzyScoreRanking(t *testing.T) {
+	// from the best match to the worst
+	texts := []string{"Push", "Push-ups", "Morning push", "repushed", "Pusj", "Squats"}
+
+	for i := 1; i < len(texts); i++ {
+		better, worse := FuzzyScore("push", texts[i-1]), FuzzyScore("push", texts[i])
+		if better <= worse {
+			t.Errorf("FuzzyScore(%q, %q) = %v, want more than %q with %v", "push", texts[i-1], better, texts[i], worse)
+		}
+	}
+}
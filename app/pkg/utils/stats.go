@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// Quantile returns the q-quantile of sorted values with linear interpolation
+// between the closest ranks.
+func Quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	if lower == upper {
+		return sorted[lower]
+	}
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+}
+
+type Summary struct {
+	Count  int     `json:"count"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	P90    float64 `json:"p90"`
+	P95    float64 `json:"p95"`
+	StdDev float64 `json:"stdDev"`
+}
+
+// Summarize describes the distribution of values, the standard deviation is
+// the population one.
+func Summarize(values []float64) Summary {
+	summary := Summary{Count: len(values)}
+	if len(values) == 0 {
+		return summary
+	}
+
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	summary.Mean = Mean(sorted)
+
+	var variance float64
+	for _, value := range sorted {
+		variance += (value - summary.Mean) * (value - summary.Mean)
+	}
+	summary.StdDev = math.Sqrt(variance / float64(len(sorted)))
+
+	summary.Min = sorted[0]
+	summary.Max = sorted[len(sorted)-1]
+	summary.Median = Quantile(sorted, 0.5)
+	summary.P90 = Quantile(sorted, 0.9)
+	summary.P95 = Quantile(sorted, 0.95)
+
+	return summary
+}
+
+// LinearRegression fits values against their index with least squares and
+// returns the slope, the intercept and the coefficient of determination.
+func LinearRegression(values []float64) (float64, float64, float64) {
+	n := float64(len(values))
+	if n < 2 {
+		return 0, Mean(values), 0
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i, y := range values {
+		x := float64(i)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	slope := (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	intercept := (sumY - slope*sumX) / n
+
+	meanY := sumY / n
+	var ssTot, ssRes float64
+	for i, y := range values {
+		predicted := intercept + slope*float64(i)
+		ssTot += (y - meanY) * (y - meanY)
+		ssRes += (y - predicted) * (y - predicted)
+	}
+
+	r2 := 0.0
+	if ssTot != 0 {
+		r2 = 1 - ssRes/ssTot
+	}
+
+	return slope, intercept, r2
+}
+
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, value := range values {
+		sum += value
+	}
+
+	return sum / float64(len(values))
+}
+
+// Pearson returns the correlation coefficient of x and y, false when it is
+// undefined because of too few values or a constant series.
+func Pearson(x []float64, y []float64) (float64, bool) {
+	n := min(len(x), len(y))
+	if n < 2 {
+		return 0, false
+	}
+
+	meanX, meanY := Mean(x[:n]), Mean(y[:n])
+
+	var cov, varX, varY float64
+	for i := 0; i < n; i++ {
+		cov += (x[i] - meanX) * (y[i] - meanY)
+		varX += (x[i] - meanX) * (x[i] - meanX)
+		varY += (y[i] - meanY) * (y[i] - meanY)
+	}
+	if varX == 0 || varY == 0 {
+		return 0, false
+	}
+
+	return cov / math.Sqrt(varX*varY), true
+}
+
+// Spearman returns the rank correlation coefficient of x and y.
+func Spearman(x []float64, y []float64) (float64, bool) {
+	return Pearson(Ranks(x), Ranks(y))
+}
+
+// Ranks returns the 1-based rank of each value, ties get their average rank.
+func Ranks(values []float64) []float64 {
+	indexes := make([]int, len(values))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	slices.SortStableFunc(indexes, func(a, b int) int {
+		return cmp.Compare(values[a], values[b])
+	})
+
+	ranks := make([]float64, len(values))
+	for i := 0; i < len(indexes); {
+		j := i
+		for j+1 < len(indexes) && values[indexes[j+1]] == values[indexes[i]] {
+			j++
+		}
+
+		rank := float64(i+j)/2 + 1
+		for k := i; k <= j; k++ {
+			ranks[indexes[k]] = rank
+		}
+		i = j + 1
+	}
+
+	return ranks
+}
@@ -1,6 +1,11 @@
 package utils
 
-import "slices"
+import (
+	"regexp"
+	"slices"
+)
+
+var hexColor = regexp.MustCompile("^#[0-9a-fA-F]{6}$")
 
 func StringToBool(str string) bool {
 	trulyValues := []string{"1", "T", "t", "TRUE", "True", "true", "ON", "On", "on"}
@@ -8,3 +13,7 @@ func StringToBool(str string) bool {
 	return slices.Contains(trulyValues, str)
 
 }
+
+func IsHexColor(str string) bool {
+	return hexColor.MatchString(str)
+}
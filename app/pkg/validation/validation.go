@@ -0,0 +1,111 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"main/app/models"
+	"main/app/pkg/utils"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Msg   string `json:"msg"`
+}
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// report the json name of the fields
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+
+		return name
+	})
+
+	v.RegisterValidation("timezone", func(fl validator.FieldLevel) bool {
+		_, err := utils.LoadLocation(fl.Field().String())
+		return err == nil
+	})
+	v.RegisterValidation("color", func(fl validator.FieldLevel) bool {
+		return utils.IsHexColor(fl.Field().String())
+	})
+	// an id is not a slug, so that the counters routes tell them apart
+	v.RegisterValidation("slug", func(fl validator.FieldLevel) bool {
+		return utils.IsSlug(fl.Field().String()) && !primitive.IsValidObjectID(fl.Field().String())
+	})
+
+	v.RegisterStructValidation(func(sl validator.StructLevel) {
+		counter := sl.Current().Interface().(models.Counter)
+		if counter.Min != nil && counter.Max != nil && *counter.Min > *counter.Max {
+			sl.ReportError(counter.Max, "max", "Max", "gtefield", "min")
+		}
+	}, models.Counter{})
+
+	return v
+}
+
+func message(err validator.FieldError) string {
+	switch err.Tag() {
+	case "required":
+		return fmt.Sprintf("'%s' is required", err.Field())
+	case "timezone":
+		return fmt.Sprintf("'%s' must be an IANA timezone", err.Field())
+	case "color":
+		return fmt.Sprintf("'%s' must be a color like #rrggbb", err.Field())
+	case "slug":
+		return fmt.Sprintf("'%s' must be lowercase letters, digits and dashes, and not an id", err.Field())
+	case "gtefield":
+		return fmt.Sprintf("'%s' must be greater than or equal to '%s'", err.Field(), err.Param())
+	case "max":
+		return fmt.Sprintf("'%s' must be at most %s", err.Field(), err.Param())
+	case "http_url":
+		return fmt.Sprintf("'%s' must be an http or https URL", err.Field())
+	}
+
+	return fmt.Sprintf("'%s' failed on the '%s' rule", err.Field(), err.Tag())
+}
+
+// Struct validates s with its validate tags and returns an error for each
+// invalid field, nil when s is valid.
+func Struct(s any) []FieldError {
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []FieldError{{Rule: "invalid", Msg: err.Error()}}
+	}
+
+	fields := make([]FieldError, 0, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		fields = append(fields, FieldError{Field: fieldError.Field(), Rule: fieldError.Tag(), Msg: message(fieldError)})
+	}
+
+	return fields
+}
+
+// DataBounds checks that the number of data is within the bounds of its
+// counter.
+func DataBounds(data models.Data, counter models.Counter) []FieldError {
+	if counter.Min != nil && data.Number < *counter.Min {
+		return []FieldError{{Field: "number", Rule: "min", Msg: fmt.Sprintf("'number' must be at least %d", *counter.Min)}}
+	}
+	if counter.Max != nil && data.Number > *counter.Max {
+		return []FieldError{{Field: "number", Rule: "max", Msg: fmt.Sprintf("'number' must be at most %d", *counter.Max)}}
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package queries
+
+import (
+	"context"
+	"main/app/models"
+	"main/app/pkg/utils"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type CompareSeries struct {
+	ID     primitive.ObjectID `json:"id"`
+	Name   string             `json:"name"`
+	Values []float64          `json:"values"`
+}
+
+type LaggedCorrelation struct {
+	Lag     int      `json:"lag"`
+	Pearson *float64 `json:"pearson"`
+}
+
+type Correlation struct {
+	A        primitive.ObjectID  `json:"a"`
+	B        primitive.ObjectID  `json:"b"`
+	Pearson  *float64            `json:"pearson"`
+	Spearman *float64            `json:"spearman"`
+	Lagged   []LaggedCorrelation `json:"lagged"`
+	// BestLag is the lag with the strongest correlation, positive when B
+	// follows A.
+	BestLag *int `json:"bestLag"`
+}
+
+type Comparison struct {
+	Interval     string          `json:"interval"`
+	Dates        []string        `json:"dates"`
+	Series       []CompareSeries `json:"series"`
+	Correlations []Correlation   `json:"correlations"`
+}
+
+type compareGroup struct {
+	ID struct {
+		Counter primitive.ObjectID `bson:"counter"`
+		Start   time.Time          `bson:"start"`
+	} `bson:"_id"`
+	Sum int `bson:"sum"`
+}
+
+func correlation(value float64, ok bool) *float64 {
+	if !ok {
+		return nil
+	}
+
+	return &value
+}
+
+// CompareCounters aligns the series of the counters on the range where all of
+// them have data, with the same bucketing as GetCounterSeries, and correlates
+// each pair with lags up to maxLag buckets in both directions.
+func (q *DataQueries) CompareCounters(counters []models.Counter, interval string, maxLag int, opts CounterOptions) (Comparison, error) {
+	comparison := Comparison{
+		Interval:     interval,
+		Dates:        []string{},
+		Series:       []CompareSeries{},
+		Correlations: []Correlation{},
+	}
+	loc := opts.location()
+
+	// the data before the latest start is never part of the aligned range
+	var ids []primitive.ObjectID
+	var start time.Time
+	for _, counter := range counters {
+		ids = append(ids, counter.ID)
+		if counterStart := opts.start(counter); counterStart.After(start) {
+			start = counterStart
+		}
+	}
+
+	createdAt := bson.M{"$gte": primitive.NewDateTimeFromTime(start)}
+	if opts.To != nil {
+		createdAt["$lt"] = primitive.NewDateTimeFromTime(*opts.To)
+	}
+
+	matchStage := bson.D{{
+		Key: "$match",
+		Value: bson.M{
+			"counter_ref": bson.M{"$in": ids},
+			"createdAt":   createdAt,
+		},
+	}}
+	groupStage := bson.D{
+		{
+			Key: "$group",
+			Value: bson.D{
+				{
+					Key: "_id",
+					Value: bson.D{
+						{Key: "counter", Value: "$counter_ref"},
+						{Key: "start", Value: truncateCreatedAt(interval, loc)},
+					},
+				},
+				{Key: "sum", Value: bson.D{{Key: "$sum", Value: "$number"}}},
+			},
+		}}
+
+	var groups []compareGroup
+	pipeline := mongo.Pipeline{matchStage, groupStage}
+	cursor, err := q.Collection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return comparison, err
+	}
+	if err = cursor.All(context.TODO(), &groups); err != nil {
+		return comparison, err
+	}
+
+	sums := make(map[primitive.ObjectID]map[int64]int, len(counters))
+	first := make(map[primitive.ObjectID]time.Time, len(counters))
+	for _, group := range groups {
+		if sums[group.ID.Counter] == nil {
+			sums[group.ID.Counter] = map[int64]int{}
+		}
+		sums[group.ID.Counter][group.ID.Start.Unix()] = group.Sum
+
+		if current, ok := first[group.ID.Counter]; !ok || group.ID.Start.Before(current) {
+			first[group.ID.Counter] = group.ID.Start
+		}
+	}
+
+	// counters without a start begin with their first bucket
+	for _, counter := range counters {
+		if !opts.start(counter).IsZero() {
+			continue
+		}
+		counterFirst, ok := first[counter.ID]
+		if !ok {
+			return comparison, nil
+		}
+		if counterFirst.After(start) {
+			start = counterFirst
+		}
+	}
+	if start.IsZero() {
+		return comparison, nil
+	}
+
+	end := opts.end(time.Now())
+	var starts []int64
+	for t := utils.TruncateInterval(start.In(loc), interval); t.Before(end); t = utils.AddInterval(t, interval, 1) {
+		if len(starts) == maxSeriesBuckets {
+			return comparison, ErrTooManyBuckets
+		}
+
+		starts = append(starts, t.Unix())
+		comparison.Dates = append(comparison.Dates, utils.FormatInterval(t, interval))
+	}
+
+	for _, counter := range counters {
+		series := CompareSeries{ID: counter.ID, Name: counter.Name, Values: make([]float64, len(starts))}
+		for i, t := range starts {
+			series.Values[i] = float64(sums[counter.ID][t])
+		}
+		comparison.Series = append(comparison.Series, series)
+	}
+
+	for i, a := range comparison.Series {
+		for _, b := range comparison.Series[i+1:] {
+			pair := Correlation{
+				A:        a.ID,
+				B:        b.ID,
+				Pearson:  correlation(utils.Pearson(a.Values, b.Values)),
+				Spearman: correlation(utils.Spearman(a.Values, b.Values)),
+				Lagged:   []LaggedCorrelation{},
+			}
+
+			var best float64
+			for lag := -maxLag; lag <= maxLag; lag++ {
+				var value *float64
+				if lag >= 0 && lag < len(starts) {
+					value = correlation(utils.Pearson(a.Values[:len(starts)-lag], b.Values[lag:]))
+				} else if lag < 0 && -lag < len(starts) {
+					value = correlation(utils.Pearson(a.Values[-lag:], b.Values[:len(starts)+lag]))
+				}
+				pair.Lagged = append(pair.Lagged, LaggedCorrelation{Lag: lag, Pearson: value})
+
+				if value != nil && (pair.BestLag == nil || math.Abs(*value) > best) {
+					best = math.Abs(*value)
+					pair.BestLag = &lag
+				}
+			}
+
+			comparison.Correlations = append(comparison.Correlations, pair)
+		}
+	}
+
+	return comparison, nil
+}
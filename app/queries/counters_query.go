@@ -1,27 +1,108 @@
 package queries
 
 import (
+	"cmp"
 	"context"
+	"fmt"
 	"main/app/models"
+	"main/app/pkg/utils"
+	"slices"
+	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type CounterQueries struct {
 	Collection *mongo.Collection
 }
 
+// maxSlugAttempts bounds the numbered suffixes tried for a generated slug.
+const maxSlugAttempts = 100
+
+// EnsureIndexes creates the unique index of the slugs and gives a slug to the
+// counters created before them.
+func (q *CounterQueries) EnsureIndexes() error {
+	index := mongo.IndexModel{
+		Keys: bson.D{{Key: "slug", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"slug": bson.M{"$exists": true}}),
+	}
+	if _, err := q.Collection.Indexes().CreateOne(context.TODO(), index); err != nil {
+		return err
+	}
+
+	var counters []models.Counter
+	cursor, err := q.Collection.Find(context.TODO(), bson.M{"slug": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	if err = cursor.All(context.TODO(), &counters); err != nil {
+		return err
+	}
+
+	for _, counter := range counters {
+		_, err := uniqueSlug(counter.Name, func(slug string) error {
+			_, err := q.Collection.UpdateByID(context.TODO(), counter.ID, bson.M{"$set": bson.M{"slug": slug}})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// uniqueSlug calls write with the slug of name, then with numbered suffixes
+// while the slug is used by another counter.
+func uniqueSlug(name string, write func(slug string) error) (string, error) {
+	base := utils.Slugify(name)
+	if base == "" || primitive.IsValidObjectID(base) {
+		base = strings.Trim("counter-"+base, "-")
+	}
+	base = strings.TrimRight(base[:min(len(base), 90)], "-")
+
+	slug := base
+	for i := 2; ; i++ {
+		err := write(slug)
+		if !mongo.IsDuplicateKeyError(err) || i > maxSlugAttempts {
+			return slug, err
+		}
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// CreateCounter generates the slug of the counter from its name when it has
+// none.
 func (q *CounterQueries) CreateCounter(newCounter models.Counter) (models.Counter, error) {
 	var counter models.Counter
 
-	result, err := q.Collection.InsertOne(context.TODO(), newCounter)
+	var id any
+	insert := func(slug string) error {
+		newCounter.Slug = slug
+		result, err := q.Collection.InsertOne(context.TODO(), newCounter)
+		if err == nil {
+			id = result.InsertedID
+		}
+		return err
+	}
+
+	var err error
+	if newCounter.Slug != "" {
+		err = insert(newCounter.Slug)
+	} else {
+		_, err = uniqueSlug(newCounter.Name, insert)
+	}
 	if err != nil {
 		return counter, err
 	}
 
-	filters := bson.D{{Key: "_id", Value: result.InsertedID}}
+	filters := bson.D{{Key: "_id", Value: id}}
 	err = q.Collection.FindOne(context.TODO(), filters).Decode(&counter)
 	if err != nil {
 		return counter, err
@@ -44,60 +125,170 @@ func (q *CounterQueries) GetCounters() ([]models.Counter, error) {
 	return counters, nil
 }
 
-func (q *CounterQueries) GetCounter(counterID string) (models.Counter, error) {
-	var counter models.Counter
+func (q *CounterQueries) GetCountersByIDs(ids []primitive.ObjectID) ([]models.Counter, error) {
+	var counters []models.Counter
 
-	id, err := primitive.ObjectIDFromHex(counterID)
+	filters := bson.M{"_id": bson.M{"$in": ids}}
+	cursor, err := q.Collection.Find(context.TODO(), filters)
 	if err != nil {
-		return counter, err
+		return counters, err
+	}
+	if err = cursor.All(context.TODO(), &counters); err != nil {
+		return counters, err
 	}
 
-	filters := bson.D{{Key: "_id", Value: id}}
-	err = q.Collection.FindOne(context.TODO(), filters).Decode(&counter)
+	return counters, nil
+}
+
+type counterMatch struct {
+	counter models.Counter
+	score   float64
+}
+
+// SearchCounters returns the counters whose name is close to query, the best
+// matches first.
+func (q *CounterQueries) SearchCounters(query string) ([]models.Counter, error) {
+	counters, err := q.GetCounters()
 	if err != nil {
-		return counter, err
+		return counters, err
 	}
 
-	return counter, nil
+	var matches []counterMatch
+	for _, counter := range counters {
+		if score := utils.FuzzyScore(query, counter.Name); score > 0 {
+			matches = append(matches, counterMatch{counter, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b counterMatch) int {
+		if a.score != b.score {
+			return cmp.Compare(b.score, a.score)
+		}
+		return strings.Compare(a.counter.Name, b.counter.Name)
+	})
+
+	found := make([]models.Counter, len(matches))
+	for i, match := range matches {
+		found[i] = match.counter
+	}
+
+	return found, nil
 }
 
-func (q *CounterQueries) EditCounter(counter models.Counter) (bool, error) {
-	update := bson.M{
-		"$set": bson.M{
-			"name":      counter.Name,
-			"softReset": counter.SoftReset,
-			"updatedAt": counter.UpdatedAt,
+// Version identifies the state of a set of documents cheaply, it changes on
+// every insert, update or delete.
+type Version struct {
+	LastModified time.Time `bson:"lastModified"`
+	Count        int64     `bson:"count"`
+}
+
+func collectionVersion(collection *mongo.Collection, filters bson.M) (Version, error) {
+	var version Version
+
+	matchStage := bson.D{{Key: "$match", Value: filters}}
+	groupStage := bson.D{{
+		Key: "$group",
+		Value: bson.M{
+			"_id":          nil,
+			"lastModified": bson.M{"$max": "$updatedAt"},
+			"count":        bson.M{"$sum": 1},
 		},
+	}}
+
+	cursor, err := collection.Aggregate(context.TODO(), mongo.Pipeline{matchStage, groupStage})
+	if err != nil {
+		return version, err
+	}
+	if cursor.Next(context.TODO()) {
+		err = cursor.Decode(&version)
 	}
-	res, err := q.Collection.UpdateByID(context.TODO(), counter.ID, update)
 	if err != nil {
-		return false, err
+		return version, err
 	}
 
-	if res.ModifiedCount != 1 {
-		return false, nil
-	}
+	return version, cursor.Err()
+}
 
-	return true, nil
+func (q *CounterQueries) GetCountersVersion() (Version, error) {
+	return collectionVersion(q.Collection, bson.M{})
 }
 
-func (q *CounterQueries) DeleteCounter(counterID string) error {
-	id, err := primitive.ObjectIDFromHex(counterID)
+// GetCounter finds a counter by id or by slug.
+func (q *CounterQueries) GetCounter(idOrSlug string) (models.Counter, error) {
+	var counter models.Counter
+
+	filters := bson.D{{Key: "slug", Value: idOrSlug}}
+	if id, err := primitive.ObjectIDFromHex(idOrSlug); err == nil {
+		filters = bson.D{{Key: "_id", Value: id}}
+	}
+
+	err := q.Collection.FindOne(context.TODO(), filters).Decode(&counter)
 	if err != nil {
+		return counter, err
+	}
+
+	return counter, nil
+}
+
+// EditCounter saves counter only if it was not updated since previous, false
+// means it was modified or deleted in the meantime. A cleared slug is
+// generated from the name again.
+func (q *CounterQueries) EditCounter(counter *models.Counter, previous primitive.DateTime) (bool, error) {
+	matched := false
+	update := func(slug string) error {
+		update := bson.M{
+			"$set": bson.M{
+				"name":      counter.Name,
+				"slug":      slug,
+				"softReset": counter.SoftReset,
+				"timezone":  counter.Timezone,
+				"color":     counter.Color,
+				"min":       counter.Min,
+				"max":       counter.Max,
+				"updatedAt": counter.UpdatedAt,
+			},
+		}
+		filters := bson.M{"_id": counter.ID, "updatedAt": previous}
+		res, err := q.Collection.UpdateOne(context.TODO(), filters, update)
+		if err == nil {
+			matched = res.MatchedCount == 1
+		}
 		return err
 	}
 
-	filters := bson.D{{Key: "counter_ref", Value: id}}
-	_, err = q.Collection.Database().Collection("datas").DeleteMany(context.TODO(), filters)
+	if counter.Slug != "" {
+		return matched, update(counter.Slug)
+	}
+
+	slug, err := uniqueSlug(counter.Name, update)
 	if err != nil {
-		return err
+		return false, err
+	}
+	if matched {
+		counter.Slug = slug
 	}
 
-	filters = bson.D{{Key: "_id", Value: id}}
-	_, err = q.Collection.DeleteOne(context.TODO(), filters)
+	return matched, nil
+}
+
+// DeleteCounter removes counter and its data only if it was not updated
+// since it was read, false means it was modified or deleted in the meantime.
+func (q *CounterQueries) DeleteCounter(counter models.Counter) (bool, error) {
+	filters := bson.D{{Key: "_id", Value: counter.ID}, {Key: "updatedAt", Value: counter.UpdatedAt}}
+	res, err := q.Collection.DeleteOne(context.TODO(), filters)
 	if err != nil {
-		return err
+		return false, err
+	}
+	if res.DeletedCount != 1 {
+		return false, nil
 	}
 
-	return nil
+	filters = bson.D{{Key: "counter_ref", Value: counter.ID}}
+	for _, collection := range []string{"datas", "triggers"} {
+		_, err = q.Collection.Database().Collection(collection).DeleteMany(context.TODO(), filters)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
 }
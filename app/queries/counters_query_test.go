@@ -0,0 +1,39 @@
+package queries
+
+import (
+	"slices"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestUniqueSlug(t *testing.T) {
+	duplicate := mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}}
+
+	tests := []struct {
+		name  string
+		used  []string
+		want  string
+		tries int
+	}{
+		{name: "Push-ups", want: "push-ups", tries: 1},
+		{name: "Push-ups", used: []string{"push-ups", "push-ups-2"}, want: "push-ups-3", tries: 3},
+		{name: "!!!", want: "counter", tries: 1},
+		// an ObjectID-like slug would shadow the ids in the lookups
+		{name: "507F1F77BCF86CD799439011", want: "counter-507f1f77bcf86cd799439011", tries: 1},
+	}
+
+	for _, test := range tests {
+		tries := 0
+		slug, err := uniqueSlug(test.name, func(slug string) error {
+			tries++
+			if slices.Contains(test.used, slug) {
+				return duplicate
+			}
+			return nil
+		})
+		if err != nil || slug != test.want || tries != test.tries {
+			t.Errorf("uniqueSlug(%q) = %q, %v after %d tries, want %q after %d", test.name, slug, err, tries, test.want, test.tries)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package queries
+
+import (
+	"context"
+	"main/app/models"
+	"main/app/pkg/utils"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type DashboardOptions struct {
+	Global bool
+	// Timezone overrides the timezone of every counter when set.
+	Timezone string
+	// SparklineDays is the number of days of the sparkline, today included.
+	SparklineDays int
+}
+
+type DayTotal struct {
+	Date  string `json:"date"`
+	Total int    `json:"total"`
+}
+
+type DashboardStats struct {
+	Total int     `json:"total"`
+	Avg   float64 `json:"avg"`
+	Days  int     `json:"days"`
+}
+
+type DashboardCounter struct {
+	models.Counter
+	Stats     DashboardStats `json:"stats"`
+	LastEntry *models.Data   `json:"lastEntry"`
+	Today     int            `json:"today"`
+	Sparkline []DayTotal     `json:"sparkline"`
+}
+
+type dashboardSummary struct {
+	Stats []struct {
+		Total     int       `bson:"total"`
+		FirstDate time.Time `bson:"firstDate"`
+	} `bson:"stats"`
+	Last  []models.Data `bson:"last"`
+	Today []struct {
+		Total int `bson:"total"`
+	} `bson:"today"`
+	Sparkline []struct {
+		Start time.Time `bson:"_id"`
+		Total int       `bson:"total"`
+	} `bson:"sparkline"`
+}
+
+type dashboardDocument struct {
+	models.Counter `bson:",inline"`
+	Summary        []dashboardSummary `bson:"summary"`
+}
+
+// GetDashboard summarizes every counter with a single aggregation, the data
+// of each counter is joined and split in facets by the database.
+func (q *CounterQueries) GetDashboard(opts DashboardOptions) ([]DashboardCounter, error) {
+	var documents []dashboardDocument
+
+	timezone := bson.M{"$cond": bson.A{
+		bson.M{"$gt": bson.A{bson.M{"$ifNull": bson.A{"$$timezone", ""}}, ""}},
+		"$$timezone",
+		"UTC",
+	}}
+	if opts.Timezone != "" {
+		timezone = bson.M{"$literal": opts.Timezone}
+	}
+	today := bson.M{"$dateTrunc": bson.M{"date": "$$NOW", "unit": "day", "timezone": timezone}}
+
+	start := bson.M{"$ifNull": bson.A{"$$softReset", time.Time{}}}
+	if opts.Global {
+		start = bson.M{"$literal": time.Time{}}
+	}
+
+	lookupStage := bson.D{{
+		Key: "$lookup",
+		Value: bson.M{
+			"from": "datas",
+			"let":  bson.M{"id": "$_id", "softReset": "$softReset", "timezone": "$timezone"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{"$counter_ref", "$$id"}},
+					bson.M{"$gte": bson.A{"$createdAt", start}},
+				}}}},
+				bson.M{"$facet": bson.M{
+					"stats": bson.A{
+						bson.M{"$group": bson.M{
+							"_id":       nil,
+							"total":     bson.M{"$sum": "$number"},
+							"firstDate": bson.M{"$min": "$createdAt"},
+						}},
+					},
+					"last": bson.A{
+						bson.M{"$sort": bson.M{"createdAt": -1}},
+						bson.M{"$limit": 1},
+					},
+					"today": bson.A{
+						bson.M{"$match": bson.M{"$expr": bson.M{"$gte": bson.A{"$createdAt", today}}}},
+						bson.M{"$group": bson.M{"_id": nil, "total": bson.M{"$sum": "$number"}}},
+					},
+					"sparkline": bson.A{
+						bson.M{"$match": bson.M{"$expr": bson.M{"$gte": bson.A{
+							"$createdAt",
+							bson.M{"$dateSubtract": bson.M{
+								"startDate": today,
+								"unit":      "day",
+								"amount":    opts.SparklineDays - 1,
+								"timezone":  timezone,
+							}},
+						}}}},
+						bson.M{"$group": bson.M{
+							"_id":   bson.M{"$dateTrunc": bson.M{"date": "$createdAt", "unit": "day", "timezone": timezone}},
+							"total": bson.M{"$sum": "$number"},
+						}},
+					},
+				}},
+			},
+			"as": "summary",
+		},
+	}}
+	sortStage := bson.D{{Key: "$sort", Value: bson.D{{Key: "createdAt", Value: 1}}}}
+
+	pipeline := mongo.Pipeline{sortStage, lookupStage}
+	cursor, err := q.Collection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	if err = cursor.All(context.TODO(), &documents); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	dashboard := make([]DashboardCounter, 0, len(documents))
+	for _, document := range documents {
+		name := document.Timezone
+		if opts.Timezone != "" {
+			name = opts.Timezone
+		}
+		loc, err := utils.LoadLocation(name)
+		if err != nil {
+			loc = time.UTC
+		}
+
+		counter := DashboardCounter{Counter: document.Counter, Sparkline: []DayTotal{}}
+
+		var summary dashboardSummary
+		if len(document.Summary) != 0 {
+			summary = document.Summary[0]
+		}
+
+		if len(summary.Stats) != 0 {
+			counter.Stats.Total = summary.Stats[0].Total
+
+			start := summary.Stats[0].FirstDate
+			if !opts.Global && counter.SoftReset != nil {
+				start = counter.SoftReset.Time()
+			}
+			counter.Stats.Days = utils.DaysBetween(start.In(loc), now)
+			if counter.Stats.Days != 0 {
+				counter.Stats.Avg = float64(counter.Stats.Total) / float64(counter.Stats.Days)
+			}
+		}
+		if len(summary.Last) != 0 {
+			counter.LastEntry = &summary.Last[0]
+		}
+		if len(summary.Today) != 0 {
+			counter.Today = summary.Today[0].Total
+		}
+
+		totals := make(map[int64]int, len(summary.Sparkline))
+		for _, day := range summary.Sparkline {
+			totals[day.Start.Unix()] = day.Total
+		}
+
+		today := utils.StartOfDay(now.In(loc))
+		for t := today.AddDate(0, 0, 1-opts.SparklineDays); !t.After(today); t = t.AddDate(0, 0, 1) {
+			counter.Sparkline = append(counter.Sparkline, DayTotal{Date: t.Format(time.DateOnly), Total: totals[t.Unix()]})
+		}
+
+		dashboard = append(dashboard, counter)
+	}
+
+	return dashboard, nil
+}
@@ -2,8 +2,10 @@ package queries
 
 import (
 	"context"
+	"errors"
 	"main/app/models"
-	"math"
+	"main/app/pkg/utils"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,13 +19,145 @@ type DataQueries struct {
 	Collection *mongo.Collection
 }
 
+var ErrInvalidOrdering = errors.New("invalid ordering key")
+
+var dataSortKeys = map[string]string{
+	"createdAt":  "createdAt",
+	"updatedAt":  "updatedAt",
+	"number":     "number",
+	"counterRef": "counter_ref",
+}
+
+// objectID parses the id of a resource, a malformed id is ErrInvalidHex
+// whether its length or its digits are wrong.
+func objectID(hex string) (primitive.ObjectID, error) {
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return id, primitive.ErrInvalidHex
+	}
+
+	return id, nil
+}
+
 type ListOptions struct {
 	Limit    int64
 	Ordering string
+
+	Counters  []primitive.ObjectID
+	From      *time.Time
+	To        *time.Time
+	MinNumber *int
+	MaxNumber *int
+	Tags      []string
+}
+
+func (opts ListOptions) filters() bson.M {
+	filters := bson.M{}
+
+	if len(opts.Counters) == 1 {
+		filters["counter_ref"] = opts.Counters[0]
+	} else if len(opts.Counters) > 1 {
+		filters["counter_ref"] = bson.M{"$in": opts.Counters}
+	}
+
+	createdAt := bson.M{}
+	if opts.From != nil {
+		createdAt["$gte"] = primitive.NewDateTimeFromTime(*opts.From)
+	}
+	if opts.To != nil {
+		createdAt["$lt"] = primitive.NewDateTimeFromTime(*opts.To)
+	}
+	if len(createdAt) != 0 {
+		filters["createdAt"] = createdAt
+	}
+
+	number := bson.M{}
+	if opts.MinNumber != nil {
+		number["$gte"] = *opts.MinNumber
+	}
+	if opts.MaxNumber != nil {
+		number["$lte"] = *opts.MaxNumber
+	}
+	if len(number) != 0 {
+		filters["number"] = number
+	}
+
+	if len(opts.Tags) != 0 {
+		filters["tags"] = bson.M{"$all": opts.Tags}
+	}
+
+	return filters
+}
+
+func (opts ListOptions) sort() (bson.D, error) {
+	if opts.Ordering == "" {
+		return bson.D{{Key: "createdAt", Value: 1}}, nil
+	}
+
+	direction := 1
+	ordering := opts.Ordering
+	if key, found := strings.CutPrefix(ordering, "-"); found {
+		direction = -1
+		ordering = key
+	}
+
+	key, ok := dataSortKeys[ordering]
+	if !ok {
+		return nil, ErrInvalidOrdering
+	}
+
+	return bson.D{{Key: key, Value: direction}}, nil
 }
 
 type CounterOptions struct {
-	Global bool
+	Global   bool
+	From     *time.Time
+	To       *time.Time
+	Location *time.Location
+}
+
+func (opts CounterOptions) location() *time.Location {
+	if opts.Location == nil {
+		return time.UTC
+	}
+
+	return opts.Location
+}
+
+// start returns the lower bound of the counter data, the latest between the
+// soft reset (ignored when global) and the requested range start.
+func (opts CounterOptions) start(counter models.Counter) time.Time {
+	var from time.Time
+	if !opts.Global && counter.SoftReset != nil {
+		from = counter.SoftReset.Time()
+	}
+	if opts.From != nil && opts.From.After(from) {
+		from = *opts.From
+	}
+
+	return from.In(opts.location())
+}
+
+// end returns the upper bound of the counter data, never after now.
+func (opts CounterOptions) end(now time.Time) time.Time {
+	if opts.To != nil && opts.To.Before(now) {
+		return opts.To.In(opts.location())
+	}
+
+	return now.In(opts.location())
+}
+
+func (opts CounterOptions) createdAtFilter(counter models.Counter) bson.M {
+	filter := bson.M{"$gte": primitive.NewDateTimeFromTime(opts.start(counter))}
+	if opts.To != nil {
+		filter["$lt"] = primitive.NewDateTimeFromTime(*opts.To)
+	}
+
+	return filter
+}
+
+func (q *DataQueries) GetCounterDataVersion(counter models.Counter) (Version, error) {
+	return collectionVersion(q.Collection, bson.M{"counter_ref": counter.ID})
 }
 
 func (q *DataQueries) CreateData(newdata models.Data) (models.Data, error) {
@@ -46,22 +180,17 @@ func (q *DataQueries) CreateData(newdata models.Data) (models.Data, error) {
 func (q *DataQueries) GetDatas(opts ListOptions) ([]models.Data, error) {
 	var data []models.Data
 
-	qopts := options.Find()
-	if opts.Ordering != "" {
-		if strings.HasPrefix(opts.Ordering, "-") {
-			key, _ := strings.CutPrefix(opts.Ordering, "-")
-			qopts.SetSort(bson.D{{Key: key, Value: -1}})
-		} else {
-			qopts.SetSort(bson.D{{Key: opts.Ordering, Value: 1}})
-		}
-	} else {
-		qopts.SetSort(bson.D{{Key: "createdAt", Value: 1}})
+	sort, err := opts.sort()
+	if err != nil {
+		return data, err
 	}
+
+	qopts := options.Find().SetSort(sort)
 	if opts.Limit != 0 {
 		qopts.SetLimit(opts.Limit)
 	}
 
-	cursor, err := q.Collection.Find(context.TODO(), bson.D{{}}, qopts)
+	cursor, err := q.Collection.Find(context.TODO(), opts.filters(), qopts)
 	if err != nil {
 		return data, err
 	}
@@ -75,7 +204,7 @@ func (q *DataQueries) GetDatas(opts ListOptions) ([]models.Data, error) {
 func (q *DataQueries) GetData(dataID string) (models.Data, error) {
 	var data models.Data
 
-	id, err := primitive.ObjectIDFromHex(dataID)
+	id, err := objectID(dataID)
 	if err != nil {
 		return data, err
 	}
@@ -90,7 +219,7 @@ func (q *DataQueries) GetData(dataID string) (models.Data, error) {
 }
 
 func (q *DataQueries) DeleteData(dataID string) (bool, error) {
-	id, err := primitive.ObjectIDFromHex(dataID)
+	id, err := objectID(dataID)
 	if err != nil {
 		return false, err
 	}
@@ -104,186 +233,277 @@ func (q *DataQueries) DeleteData(dataID string) (bool, error) {
 	return res.DeletedCount == 1, nil
 }
 
-func (q *DataQueries) GetCounterSum(counter models.Counter, opts CounterOptions) (bson.M, error) {
-	var data bson.M
+// ExportDatas returns a cursor over the data of the queries ordered by
+// creation, the caller closes it.
+func (q *DataQueries) ExportDatas(counterQueries []CounterQuery) (*mongo.Cursor, error) {
+	filters := make(bson.A, len(counterQueries))
+	for i, query := range counterQueries {
+		filters[i] = bson.M{
+			"counter_ref": query.Counter.ID,
+			"createdAt":   query.Options.createdAtFilter(query.Counter),
+		}
+	}
+	if len(filters) == 0 {
+		filters = bson.A{bson.M{"_id": bson.M{"$exists": false}}}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+	return q.Collection.Find(context.TODO(), bson.M{"$or": filters}, opts)
+}
+
+// UndoLastData deletes the last data written for counter since the given
+// time, it returns mongo.ErrNoDocuments when there is none.
+func (q *DataQueries) UndoLastData(counter models.Counter, since time.Time) (models.Data, error) {
+	var data models.Data
 
-	var softResetDate primitive.DateTime
-	if opts.Global || counter.SoftReset == nil {
-		softResetDate = primitive.NewDateTimeFromTime(time.Time{})
-	} else {
-		softResetDate = *counter.SoftReset
+	filters := bson.M{
+		"counter_ref": counter.ID,
+		"updatedAt":   bson.M{"$gte": primitive.NewDateTimeFromTime(since)},
 	}
+	opts := options.FindOneAndDelete().SetSort(bson.D{{Key: "_id", Value: -1}})
+	err := q.Collection.FindOneAndDelete(context.TODO(), filters, opts).Decode(&data)
+
+	return data, err
+}
+
+// StatsVersion is the version of the shape of the statistics responses.
+const StatsVersion = 1
+
+type CounterSum struct {
+	Version   int                `json:"version"`
+	CounterID primitive.ObjectID `json:"counterId"`
+	Total     int                `json:"total"`
+}
+
+type CounterAvg struct {
+	Version   int                `json:"version"`
+	CounterID primitive.ObjectID `json:"counterId"`
+	Avg       float64            `json:"avg"`
+	Days      int                `json:"days"`
+}
+
+type CounterStats struct {
+	Version   int                `json:"version"`
+	CounterID primitive.ObjectID `json:"counterId"`
+	Total     int                `json:"total"`
+	Avg       float64            `json:"avg"`
+	Days      int                `json:"days"`
+
+	// set by the extended statistics only
+	PerDay     *utils.Summary `json:"perDay,omitempty"`
+	PerEntry   *utils.Summary `json:"perEntry,omitempty"`
+	BusiestDay *SeriesBucket  `json:"busiestDay,omitempty"`
+}
+
+type counterTotal struct {
+	Total     int       `bson:"total"`
+	FirstDate time.Time `bson:"firstDate"`
+}
+
+// getCounterTotal sums the data of counter, the first date is zero when there
+// is no data.
+func (q *DataQueries) getCounterTotal(counter models.Counter, opts CounterOptions) (counterTotal, error) {
+	var data counterTotal
 
 	matchStage := bson.D{{
 		Key: "$match",
 		Value: bson.M{
 			"counter_ref": counter.ID,
-			"createdAt":   bson.M{"$gte": softResetDate},
+			"createdAt":   opts.createdAtFilter(counter),
 		},
 	}}
-	sortStage := bson.D{{
-		Key:   "$sort",
-		Value: bson.D{{Key: "createdAt", Value: 1}},
-	}}
 	groupStage := bson.D{
 		{
 			Key: "$group",
 			Value: bson.D{
-				{
-					Key:   "_id",
-					Value: "$counter_ref",
-				},
+				{Key: "_id", Value: "$counter_ref"},
 				{Key: "total", Value: bson.D{{Key: "$sum", Value: "$number"}}},
+				{Key: "firstDate", Value: bson.D{{Key: "$min", Value: "$createdAt"}}},
 			},
 		}}
 
-	pipeline := mongo.Pipeline{matchStage, sortStage, groupStage}
+	pipeline := mongo.Pipeline{matchStage, groupStage}
 	cursor, err := q.Collection.Aggregate(context.TODO(), pipeline)
 	if err != nil {
 		return data, err
 	}
-
-	cursor.Next(context.TODO())
-
-	if err = cursor.Err(); err != nil {
+	if cursor.Next(context.TODO()) {
+		err = cursor.Decode(&data)
+	}
+	if err != nil {
 		return data, err
 	}
-	cursor.Decode(&data)
 
-	return data, nil
+	return data, cursor.Err()
 }
 
-func (q *DataQueries) GetCounterAvg(counter models.Counter, opts CounterOptions) (bson.M, error) {
-	var data bson.M
+// counterDays counts the days from the start of the range, or from the first
+// data without one, to its end.
+func (opts CounterOptions) counterDays(counter models.Counter, data counterTotal) int {
+	start := opts.start(counter)
+	if start.IsZero() {
+		if data.FirstDate.IsZero() {
+			return 0
+		}
+		start = data.FirstDate.In(opts.location())
+	}
+
+	return utils.DaysBetween(start, opts.end(time.Now()))
+}
 
-	var softResetDate primitive.DateTime
-	if opts.Global || counter.SoftReset == nil {
-		softResetDate = primitive.NewDateTimeFromTime(time.Time{})
-	} else {
-		softResetDate = *counter.SoftReset
+func average(total int, days int) float64 {
+	if days == 0 {
+		return 0
 	}
 
-	matchStage := bson.D{{
-		Key: "$match",
-		Value: bson.M{
-			"counter_ref": counter.ID,
-			"createdAt":   bson.M{"$gte": softResetDate},
-		},
-	}}
-	sortStage := bson.D{{
-		Key:   "$sort",
-		Value: bson.D{{Key: "createdAt", Value: 1}},
-	}}
-	groupStage := bson.D{
-		{
-			Key: "$group",
-			Value: bson.D{
-				{Key: "_id", Value: "$counter_ref"},
-				{Key: "total", Value: bson.D{{Key: "$sum", Value: "$number"}}},
-				{Key: "firstDate", Value: bson.D{{Key: "$min", Value: "$createdAt"}}},
-				{Key: "lastDate", Value: bson.D{{Key: "$max", Value: "$createdAt"}}},
-			},
-		}}
+	return float64(total) / float64(days)
+}
 
-	pipeline := mongo.Pipeline{matchStage, sortStage, groupStage}
-	cursor, err := q.Collection.Aggregate(context.TODO(), pipeline)
+func (q *DataQueries) GetCounterSum(counter models.Counter, opts CounterOptions) (CounterSum, error) {
+	sum := CounterSum{Version: StatsVersion, CounterID: counter.ID}
+
+	data, err := q.getCounterTotal(counter, opts)
 	if err != nil {
-		return data, err
+		return sum, err
 	}
+	sum.Total = data.Total
 
-	cursor.Next(context.TODO())
+	return sum, nil
+}
 
-	if err = cursor.Err(); err != nil {
-		return data, err
+func (q *DataQueries) GetCounterAvg(counter models.Counter, opts CounterOptions) (CounterAvg, error) {
+	avg := CounterAvg{Version: StatsVersion, CounterID: counter.ID}
+
+	data, err := q.getCounterTotal(counter, opts)
+	if err != nil {
+		return avg, err
 	}
-	cursor.Decode(&data)
+	avg.Days = opts.counterDays(counter, data)
+	avg.Avg = average(data.Total, avg.Days)
 
-	fd := data["firstDate"].(primitive.DateTime).Time().UTC()
-	ld := time.Now()
-	total := data["total"].(int32)
+	return avg, nil
+}
 
-	avg := float32(total) / float32(ld.Sub(fd)/(24*time.Hour))
+func (q *DataQueries) GetCounterStats(counter models.Counter, opts CounterOptions) (CounterStats, error) {
+	stats := CounterStats{Version: StatsVersion, CounterID: counter.ID}
 
-	return bson.M{"_id": data["_id"], "avg": avg}, nil
+	data, err := q.getCounterTotal(counter, opts)
+	if err != nil {
+		return stats, err
+	}
+	stats.Total = data.Total
+	stats.Days = opts.counterDays(counter, data)
+	stats.Avg = average(stats.Total, stats.Days)
+
+	return stats, nil
 }
 
-func (q *DataQueries) GetCounterStats(counter models.Counter, opts CounterOptions) (bson.M, error) {
-	var data bson.M
+// CounterQuery is a counter and the options of one of its statistics, to
+// compute the statistics of several counters at once.
+type CounterQuery struct {
+	Counter models.Counter
+	Options CounterOptions
+}
 
-	var softResetDate primitive.DateTime
-	if opts.Global || counter.SoftReset == nil {
-		softResetDate = primitive.NewDateTimeFromTime(time.Time{})
-	} else {
-		softResetDate = *counter.SoftReset
+// GetCountersStats computes the statistics of every query with a single
+// aggregation, the results are in the order of the queries.
+func (q *DataQueries) GetCountersStats(counterQueries []CounterQuery) ([]CounterStats, error) {
+	stats := make([]CounterStats, len(counterQueries))
+	if len(counterQueries) == 0 {
+		return stats, nil
 	}
 
-	matchStage := bson.D{{
-		Key: "$match",
-		Value: bson.M{
-			"counter_ref": counter.ID,
-			"createdAt":   bson.M{"$gte": softResetDate},
-		},
-	}}
-	sortStage := bson.D{{
-		Key:   "$sort",
-		Value: bson.D{{Key: "createdAt", Value: 1}},
-	}}
-	groupStage := bson.D{
-		{
-			Key: "$group",
-			Value: bson.D{
-				{Key: "_id", Value: "$counter_ref"},
-				{Key: "total", Value: bson.D{{Key: "$sum", Value: "$number"}}},
-				{Key: "firstDate", Value: bson.D{{Key: "$min", Value: "$createdAt"}}},
-				{Key: "lastDate", Value: bson.D{{Key: "$max", Value: "$createdAt"}}},
-			},
-		}}
+	// each query has its own facet, so overlapping ranges of a counter are
+	// all counted
+	filters := make(bson.A, len(counterQueries))
+	facets := bson.M{}
+	for i, query := range counterQueries {
+		filter := bson.M{
+			"counter_ref": query.Counter.ID,
+			"createdAt":   query.Options.createdAtFilter(query.Counter),
+		}
+		filters[i] = filter
+		facets[strconv.Itoa(i)] = bson.A{
+			bson.M{"$match": filter},
+			bson.M{"$group": bson.M{
+				"_id":       nil,
+				"total":     bson.M{"$sum": "$number"},
+				"firstDate": bson.M{"$min": "$createdAt"},
+			}},
+		}
+	}
 
-	pipeline := mongo.Pipeline{matchStage, sortStage, groupStage}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$or": filters}}},
+		{{Key: "$facet", Value: facets}},
+	}
 	cursor, err := q.Collection.Aggregate(context.TODO(), pipeline)
 	if err != nil {
-		return data, err
+		return stats, err
 	}
 
-	cursor.Next(context.TODO())
-
-	if err = cursor.Err(); err != nil {
-		return data, err
+	var results map[string][]counterTotal
+	if cursor.Next(context.TODO()) {
+		err = cursor.Decode(&results)
+	}
+	if err != nil {
+		return stats, err
+	}
+	if err := cursor.Err(); err != nil {
+		return stats, err
 	}
-	cursor.Decode(&data)
 
-	now := time.Now().UTC()
-	var days float64 = 0
-	if !opts.Global && counter.SoftReset != nil {
-		days = math.Ceil(now.Sub(counter.SoftReset.Time().UTC()).Hours() / 24)
+	for i, query := range counterQueries {
+		var total counterTotal
+		if result := results[strconv.Itoa(i)]; len(result) != 0 {
+			total = result[0]
+		}
+
+		stats[i] = CounterStats{Version: StatsVersion, CounterID: query.Counter.ID, Total: total.Total}
+		stats[i].Days = query.Options.counterDays(query.Counter, total)
+		stats[i].Avg = average(stats[i].Total, stats[i].Days)
 	}
-	if data == nil {
-		return bson.M{"_id": counter.ID, "avg": 0, "total": 0, "days": days}, nil
+
+	return stats, nil
+}
+
+// GetRecentDatas returns the latest data of each counter, newest first, with
+// a single aggregation.
+func (q *DataQueries) GetRecentDatas(counterIDs []primitive.ObjectID, limit int) (map[primitive.ObjectID][]models.Data, error) {
+	datas := map[primitive.ObjectID][]models.Data{}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"counter_ref": bson.M{"$in": counterIDs}}}},
+		{{Key: "$setWindowFields", Value: bson.M{
+			"partitionBy": "$counter_ref",
+			"sortBy":      bson.M{"createdAt": -1},
+			"output":      bson.M{"rank": bson.M{"$documentNumber": bson.M{}}},
+		}}},
+		{{Key: "$match", Value: bson.M{"rank": bson.M{"$lte": limit}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}}},
 	}
-	if days == 0 {
-		fd := data["firstDate"].(primitive.DateTime).Time().UTC()
-		days = math.Ceil(now.Sub(fd).Hours() / 24)
+	cursor, err := q.Collection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return datas, err
 	}
 
-	total := data["total"].(int32)
-	avg := float64(total) / days
+	var results []models.Data
+	if err = cursor.All(context.TODO(), &results); err != nil {
+		return datas, err
+	}
+	for _, data := range results {
+		datas[data.Counter] = append(datas[data.Counter], data)
+	}
 
-	return bson.M{"_id": data["_id"], "avg": avg, "total": total, "days": days}, nil
+	return datas, nil
 }
 
 func (q *DataQueries) GetCounterData(counter models.Counter, opts CounterOptions) ([]models.Data, error) {
 	var data []models.Data
 
-	var softResetDate primitive.DateTime
-	if opts.Global || counter.SoftReset == nil {
-		softResetDate = primitive.NewDateTimeFromTime(time.Time{})
-	} else {
-		softResetDate = *counter.SoftReset
-	}
-
 	filters := bson.M{
 		"counter_ref": counter.ID,
-		"createdAt":   bson.M{"$gte": softResetDate},
+		"createdAt":   opts.createdAtFilter(counter),
 	}
 
 	findOpts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
@@ -301,46 +521,46 @@ func (q *DataQueries) GetCounterData(counter models.Counter, opts CounterOptions
 func (q *DataQueries) GetCounterDataByMonth(counter models.Counter, opts CounterOptions) ([]bson.M, error) {
 	var data []bson.M
 
-	var softResetDate primitive.DateTime
-	if opts.Global || counter.SoftReset == nil {
-		softResetDate = primitive.NewDateTimeFromTime(time.Time{})
-	} else {
-		softResetDate = *counter.SoftReset
-	}
-
-	firstSortStage := bson.D{{Key: "$sort", Value: bson.D{{Key: "updatedAt", Value: 1}}}}
+	loc := opts.location()
 	matchStage := bson.D{{
 		Key: "$match",
 		Value: bson.M{
 			"counter_ref": counter.ID,
-			"createdAt":   bson.M{"$gte": softResetDate},
+			"createdAt":   opts.createdAtFilter(counter),
 		},
 	}}
+	// the months are the ones of the series, the data is bucketed by createdAt
 	groupStage := bson.D{
 		{
 			Key: "$group",
 			Value: bson.D{
-				{
-					Key: "_id",
-					Value: bson.D{
-						{
-							Key:   "$dateToString",
-							Value: bson.D{{Key: "format", Value: "%m-%Y"}, {Key: "date", Value: "$updatedAt"}},
-						},
-					},
-				},
+				{Key: "_id", Value: truncateCreatedAt("month", loc)},
 				{Key: "total", Value: bson.D{{Key: "$sum", Value: "$number"}}},
 			},
 		}}
+	sortStage := bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}}
 	projectStage := bson.D{
 		{
-			Key:   "$project",
-			Value: bson.D{{Key: "_id", Value: 0}, {Key: "date", Value: "$_id"}, {Key: "total", Value: 1}},
+			Key: "$project",
+			Value: bson.D{
+				{Key: "_id", Value: 0},
+				{
+					Key: "date",
+					Value: bson.D{{
+						Key: "$dateToString",
+						Value: bson.D{
+							{Key: "format", Value: "%m-%Y"},
+							{Key: "date", Value: "$_id"},
+							{Key: "timezone", Value: loc.String()},
+						},
+					}},
+				},
+				{Key: "total", Value: 1},
+			},
 		},
 	}
-	lastSortStage := bson.D{{Key: "$sort", Value: bson.D{{Key: "date", Value: 1}}}}
 
-	pipeline := mongo.Pipeline{firstSortStage, matchStage, groupStage, projectStage, lastSortStage}
+	pipeline := mongo.Pipeline{matchStage, groupStage, sortStage, projectStage}
 	cursor, err := q.Collection.Aggregate(context.TODO(), pipeline)
 	if err != nil {
 		return data, err
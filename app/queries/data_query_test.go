@@ -0,0 +1,30 @@
+package queries
+
+import (
+	"main/app/pkg/apierror"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestObjectID(t *testing.T) {
+	if _, err := objectID("507f1f77bcf86cd799439011"); err != nil {
+		t.Errorf("objectID of a valid id = %v, want no error", err)
+	}
+
+	tests := []string{
+		"",
+		"507f1f77",
+		"507f1f77bcf86cd7994390111",
+		// the length of an ObjectID but not hexadecimal
+		"zzzzzzzzzzzzzzzzzzzzzzzz",
+		"507f1f77bcf86cd79943901g",
+	}
+
+	for _, hex := range tests {
+		_, err := objectID(hex)
+		if apiError := apierror.From(err); apiError.Status != fiber.StatusBadRequest || apiError.Code != apierror.CodeInvalidID {
+			t.Errorf("objectID(%q) = %v, mapped to %d %s, want %d %s", hex, err, apiError.Status, apiError.Code, fiber.StatusBadRequest, apierror.CodeInvalidID)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package queries
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"main/app/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type FeedOptions struct {
+	Counters []primitive.ObjectID
+	// Day restricts the feed to the day starting at this time.
+	Day      *time.Time
+	Cursor   string
+	Limit    int64
+	Location *time.Location
+}
+
+type FeedCounter struct {
+	ID    primitive.ObjectID `json:"id"              bson:"_id"`
+	Name  string             `json:"name"            bson:"name"`
+	Color string             `json:"color,omitempty" bson:"color,omitempty"`
+}
+
+type FeedEntry struct {
+	models.Data `bson:",inline"`
+	CounterInfo *FeedCounter `json:"counter" bson:"counter"`
+}
+
+type FeedDay struct {
+	Date    string      `json:"date"`
+	Total   int         `json:"total"`
+	Count   int         `json:"count"`
+	Entries []FeedEntry `json:"entries"`
+}
+
+type Feed struct {
+	Days       []FeedDay `json:"days"`
+	NextCursor *string   `json:"nextCursor"`
+}
+
+// the cursor is the creation time and the id of the last entry of a page
+func encodeFeedCursor(data models.Data) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", int64(data.CreatedAt), data.ID.Hex())))
+}
+
+func decodeFeedCursor(cursor string) (primitive.DateTime, primitive.ObjectID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, primitive.NilObjectID, ErrInvalidCursor
+	}
+
+	var millis int64
+	var hex string
+	if _, err := fmt.Sscanf(string(raw), "%d:%s", &millis, &hex); err != nil {
+		return 0, primitive.NilObjectID, ErrInvalidCursor
+	}
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return 0, primitive.NilObjectID, ErrInvalidCursor
+	}
+
+	return primitive.DateTime(millis), id, nil
+}
+
+// GetFeed returns the newest entries first, joined with their counter and
+// grouped by day. The totals of a day cover the whole day, not only the
+// entries of the page.
+func (q *DataQueries) GetFeed(opts FeedOptions) (Feed, error) {
+	feed := Feed{Days: []FeedDay{}}
+	loc := opts.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	filters := bson.M{}
+	if len(opts.Counters) != 0 {
+		filters["counter_ref"] = bson.M{"$in": opts.Counters}
+	}
+	if opts.Day != nil {
+		filters["createdAt"] = bson.M{
+			"$gte": primitive.NewDateTimeFromTime(*opts.Day),
+			"$lt":  primitive.NewDateTimeFromTime(opts.Day.AddDate(0, 0, 1)),
+		}
+	}
+
+	pageFilters := bson.M{}
+	for key, value := range filters {
+		pageFilters[key] = value
+	}
+	if opts.Cursor != "" {
+		createdAt, id, err := decodeFeedCursor(opts.Cursor)
+		if err != nil {
+			return feed, err
+		}
+
+		pageFilters["$or"] = bson.A{
+			bson.M{"createdAt": bson.M{"$lt": createdAt}},
+			bson.M{"createdAt": createdAt, "_id": bson.M{"$lt": id}},
+		}
+	}
+
+	matchStage := bson.D{{Key: "$match", Value: pageFilters}}
+	sortStage := bson.D{{Key: "$sort", Value: bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}}}
+	limitStage := bson.D{{Key: "$limit", Value: opts.Limit + 1}}
+	lookupStage := bson.D{{
+		Key: "$lookup",
+		Value: bson.M{
+			"from":         "counters",
+			"localField":   "counter_ref",
+			"foreignField": "_id",
+			"pipeline":     bson.A{bson.M{"$project": bson.M{"name": 1, "color": 1}}},
+			"as":           "counter",
+		},
+	}}
+	unwindStage := bson.D{{
+		Key:   "$unwind",
+		Value: bson.M{"path": "$counter", "preserveNullAndEmptyArrays": true},
+	}}
+
+	var entries []FeedEntry
+	pipeline := mongo.Pipeline{matchStage, sortStage, limitStage, lookupStage, unwindStage}
+	cursor, err := q.Collection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return feed, err
+	}
+	if err = cursor.All(context.TODO(), &entries); err != nil {
+		return feed, err
+	}
+
+	if int64(len(entries)) > opts.Limit {
+		entries = entries[:opts.Limit]
+		next := encodeFeedCursor(entries[len(entries)-1].Data)
+		feed.NextCursor = &next
+	}
+	if len(entries) == 0 {
+		return feed, nil
+	}
+
+	for _, entry := range entries {
+		date := entry.CreatedAt.Time().In(loc).Format(time.DateOnly)
+		if len(feed.Days) == 0 || feed.Days[len(feed.Days)-1].Date != date {
+			feed.Days = append(feed.Days, FeedDay{Date: date, Entries: []FeedEntry{}})
+		}
+
+		day := &feed.Days[len(feed.Days)-1]
+		day.Entries = append(day.Entries, entry)
+	}
+
+	first, err := time.ParseInLocation(time.DateOnly, feed.Days[len(feed.Days)-1].Date, loc)
+	if err != nil {
+		return feed, err
+	}
+	last, err := time.ParseInLocation(time.DateOnly, feed.Days[0].Date, loc)
+	if err != nil {
+		return feed, err
+	}
+
+	totalsFilters := bson.M{}
+	for key, value := range filters {
+		totalsFilters[key] = value
+	}
+	totalsFilters["createdAt"] = bson.M{
+		"$gte": primitive.NewDateTimeFromTime(first),
+		"$lt":  primitive.NewDateTimeFromTime(last.AddDate(0, 0, 1)),
+	}
+
+	groupStage := bson.D{{
+		Key: "$group",
+		Value: bson.M{
+			"_id": bson.M{"$dateToString": bson.M{
+				"format":   "%Y-%m-%d",
+				"date":     "$createdAt",
+				"timezone": loc.String(),
+			}},
+			"total": bson.M{"$sum": "$number"},
+			"count": bson.M{"$sum": 1},
+		},
+	}}
+
+	var totals []struct {
+		Date  string `bson:"_id"`
+		Total int    `bson:"total"`
+		Count int    `bson:"count"`
+	}
+	pipeline = mongo.Pipeline{bson.D{{Key: "$match", Value: totalsFilters}}, groupStage}
+	cursor, err = q.Collection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return feed, err
+	}
+	if err = cursor.All(context.TODO(), &totals); err != nil {
+		return feed, err
+	}
+
+	for _, total := range totals {
+		for i := range feed.Days {
+			if feed.Days[i].Date == total.Date {
+				feed.Days[i].Total = total.Total
+				feed.Days[i].Count = total.Count
+			}
+		}
+	}
+
+	return feed, nil
+}
@@ -0,0 +1,147 @@
+package queries
+
+import (
+	"main/app/models"
+	"main/app/pkg/utils"
+	"math"
+	"time"
+)
+
+// z score of a two-sided 95% prediction interval
+const forecastZ = 1.96
+
+// how far the target eta is searched, in buckets
+var forecastETALimit = map[string]int{"day": 3660, "week": 520}
+
+type ForecastPoint struct {
+	Date  string  `json:"date"`
+	Value float64 `json:"value"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+type ForecastTarget struct {
+	Target  float64 `json:"target"`
+	Total   float64 `json:"total"`
+	Reached bool    `json:"reached"`
+	ETA     *string `json:"eta"`
+}
+
+type Forecast struct {
+	Interval string          `json:"interval"`
+	Model    string          `json:"model"`
+	History  int             `json:"history"`
+	Points   []ForecastPoint `json:"points"`
+	Target   *ForecastTarget `json:"target,omitempty"`
+}
+
+// GetCounterForecast projects the counter series with a linear trend, plus an
+// additive weekly seasonality on daily series with at least two weeks of
+// history. interval is either "day" or "week".
+func (q *DataQueries) GetCounterForecast(counter models.Counter, interval string, horizon int, target *float64, opts CounterOptions) (Forecast, error) {
+	forecast := Forecast{Interval: interval, Model: "linear", Points: []ForecastPoint{}}
+
+	series, err := q.GetCounterSeries(counter, interval, opts)
+	if err != nil {
+		return forecast, err
+	}
+	forecast.History = len(series)
+
+	values := make([]float64, len(series))
+	var total float64
+	for i, bucket := range series {
+		values[i] = float64(bucket.Sum)
+		total += values[i]
+	}
+
+	if target != nil {
+		forecast.Target = &ForecastTarget{Target: *target, Total: total, Reached: total >= *target}
+		if forecast.Target.Reached && len(series) != 0 {
+			forecast.Target.ETA = &series[len(series)-1].Date
+		}
+	}
+
+	if len(series) < 2 {
+		return forecast, nil
+	}
+
+	last, err := time.ParseInLocation(time.DateOnly, series[len(series)-1].Date, opts.location())
+	if err != nil {
+		return forecast, err
+	}
+
+	n := float64(len(values))
+	slope, intercept, _ := utils.LinearRegression(values)
+
+	// weekly seasonality, mean residual of each weekday centered on zero
+	seasonal := make([]float64, 7)
+	if interval == "day" && len(values) >= 14 {
+		forecast.Model = "linear+weekly"
+
+		counts := make([]float64, 7)
+		for i, value := range values {
+			weekday := int(last.AddDate(0, 0, i-len(values)+1).Weekday())
+			seasonal[weekday] += value - (intercept + slope*float64(i))
+			counts[weekday]++
+		}
+		for weekday := range seasonal {
+			seasonal[weekday] /= counts[weekday]
+		}
+
+		mean := utils.Mean(seasonal)
+		for weekday := range seasonal {
+			seasonal[weekday] -= mean
+		}
+	}
+
+	predict := func(i int, date time.Time) float64 {
+		value := intercept + slope*float64(i)
+		if forecast.Model == "linear+weekly" {
+			value += seasonal[int(date.Weekday())]
+		}
+
+		return value
+	}
+
+	var residuals float64
+	for i, value := range values {
+		residual := value - predict(i, utils.AddInterval(last, interval, i-len(values)+1))
+		residuals += residual * residual
+	}
+
+	stderr := 0.0
+	if n > 2 {
+		stderr = math.Sqrt(residuals / (n - 2))
+	}
+	meanX := (n - 1) / 2
+	sxx := n * (n*n - 1) / 12
+
+	for h := 1; h <= horizon; h++ {
+		i := len(values) - 1 + h
+		date := utils.AddInterval(last, interval, h)
+		value := predict(i, date)
+		spread := forecastZ * stderr * math.Sqrt(1+1/n+(float64(i)-meanX)*(float64(i)-meanX)/sxx)
+
+		forecast.Points = append(forecast.Points, ForecastPoint{
+			Date:  utils.FormatInterval(date, interval),
+			Value: value,
+			Lower: value - spread,
+			Upper: value + spread,
+		})
+	}
+
+	if forecast.Target != nil && !forecast.Target.Reached {
+		cumulative := total
+		for h := 1; h <= forecastETALimit[interval]; h++ {
+			date := utils.AddInterval(last, interval, h)
+			cumulative += predict(len(values)-1+h, date)
+			if cumulative >= *target {
+				eta := utils.FormatInterval(date, interval)
+				forecast.Target.ETA = &eta
+				break
+			}
+		}
+	}
+
+	return forecast, nil
+}
@@ -0,0 +1,87 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IdempotencyQueries stores the responses of idempotent requests, it
+// implements fiber.Storage. Keys are prefixed by Scope so the same key used on
+// two endpoints doesn't collide.
+type IdempotencyQueries struct {
+	Collection *mongo.Collection
+	Scope      string
+}
+
+type idempotencyDocument struct {
+	Key       string    `bson:"_id"`
+	Value     []byte    `bson:"value"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+// EnsureIndexes creates the TTL index which lets mongo remove the expired keys.
+func (q *IdempotencyQueries) EnsureIndexes() error {
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+	_, err := q.Collection.Indexes().CreateOne(context.TODO(), index)
+
+	return err
+}
+
+func (q *IdempotencyQueries) WithScope(scope string) *IdempotencyQueries {
+	return &IdempotencyQueries{Collection: q.Collection, Scope: scope}
+}
+
+func (q *IdempotencyQueries) key(key string) string {
+	return q.Scope + ":" + key
+}
+
+func (q *IdempotencyQueries) Get(key string) ([]byte, error) {
+	var document idempotencyDocument
+
+	// the TTL monitor is not immediate, expired keys may still be there
+	filters := bson.M{"_id": q.key(key), "expiresAt": bson.M{"$gt": time.Now()}}
+	err := q.Collection.FindOne(context.TODO(), filters).Decode(&document)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return document.Value, nil
+}
+
+func (q *IdempotencyQueries) Set(key string, value []byte, exp time.Duration) error {
+	document := idempotencyDocument{Key: q.key(key), Value: value, ExpiresAt: time.Now().Add(exp)}
+
+	opts := options.Replace().SetUpsert(true)
+	_, err := q.Collection.ReplaceOne(context.TODO(), bson.M{"_id": document.Key}, document, opts)
+
+	return err
+}
+
+func (q *IdempotencyQueries) Delete(key string) error {
+	_, err := q.Collection.DeleteOne(context.TODO(), bson.M{"_id": q.key(key)})
+
+	return err
+}
+
+func (q *IdempotencyQueries) Reset() error {
+	filters := bson.M{"_id": bson.M{"$regex": "^" + regexp.QuoteMeta(q.Scope+":")}}
+	_, err := q.Collection.DeleteMany(context.TODO(), filters)
+
+	return err
+}
+
+func (q *IdempotencyQueries) Close() error {
+	return nil
+}
@@ -0,0 +1,392 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"main/app/models"
+	"main/app/pkg/utils"
+	"math"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxSeriesBuckets = 10000
+
+var ErrTooManyBuckets = errors.New("too many buckets, use a larger interval or a shorter range")
+
+type SeriesBucket struct {
+	Date  string  `json:"date"`
+	Sum   int     `json:"sum"`
+	Count int     `json:"count"`
+	Avg   float64 `json:"avg"`
+	Min   int     `json:"min"`
+	Max   int     `json:"max"`
+}
+
+type seriesGroup struct {
+	Counter primitive.ObjectID `bson:"counter"`
+	Start   time.Time          `bson:"start"`
+	Sum     int                `bson:"sum"`
+	Count   int                `bson:"count"`
+	Min     int                `bson:"min"`
+	Max     int                `bson:"max"`
+}
+
+// truncateCreatedAt is the start of the interval of the creation date in loc,
+// the weeks start on monday.
+func truncateCreatedAt(interval string, loc *time.Location) bson.D {
+	return bson.D{{
+		Key: "$dateTrunc",
+		Value: bson.D{
+			{Key: "date", Value: "$createdAt"},
+			{Key: "unit", Value: interval},
+			{Key: "timezone", Value: loc.String()},
+			{Key: "startOfWeek", Value: "monday"},
+		},
+	}}
+}
+
+// groupCountersByInterval groups the data of the counters by interval with a
+// single aggregation, the groups of each counter are sorted by start.
+func (q *DataQueries) groupCountersByInterval(counters []models.Counter, interval string, opts CounterOptions) (map[primitive.ObjectID][]seriesGroup, error) {
+	byCounter := make(map[primitive.ObjectID][]seriesGroup, len(counters))
+	if len(counters) == 0 {
+		return byCounter, nil
+	}
+
+	filters := make(bson.A, len(counters))
+	for i, counter := range counters {
+		filters[i] = bson.M{
+			"counter_ref": counter.ID,
+			"createdAt":   opts.createdAtFilter(counter),
+		}
+	}
+
+	matchStage := bson.D{{Key: "$match", Value: bson.M{"$or": filters}}}
+	groupStage := bson.D{
+		{
+			Key: "$group",
+			Value: bson.D{
+				{
+					Key: "_id",
+					Value: bson.D{
+						{Key: "counter", Value: "$counter_ref"},
+						{Key: "start", Value: truncateCreatedAt(interval, opts.location())},
+					},
+				},
+				{Key: "sum", Value: bson.D{{Key: "$sum", Value: "$number"}}},
+				{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+				{Key: "min", Value: bson.D{{Key: "$min", Value: "$number"}}},
+				{Key: "max", Value: bson.D{{Key: "$max", Value: "$number"}}},
+			},
+		}}
+	projectStage := bson.D{{
+		Key: "$project",
+		Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "counter", Value: "$_id.counter"},
+			{Key: "start", Value: "$_id.start"},
+			{Key: "sum", Value: 1},
+			{Key: "count", Value: 1},
+			{Key: "min", Value: 1},
+			{Key: "max", Value: 1},
+		},
+	}}
+	sortStage := bson.D{{Key: "$sort", Value: bson.D{{Key: "start", Value: 1}}}}
+
+	var groups []seriesGroup
+	pipeline := mongo.Pipeline{matchStage, groupStage, projectStage, sortStage}
+	cursor, err := q.Collection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	if err = cursor.All(context.TODO(), &groups); err != nil {
+		return nil, err
+	}
+
+	for _, group := range groups {
+		byCounter[group.Counter] = append(byCounter[group.Counter], group)
+	}
+
+	return byCounter, nil
+}
+
+func (q *DataQueries) groupByInterval(counter models.Counter, interval string, opts CounterOptions) ([]seriesGroup, error) {
+	byCounter, err := q.groupCountersByInterval([]models.Counter{counter}, interval, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return byCounter[counter.ID], nil
+}
+
+func (q *DataQueries) GetCounterSeries(counter models.Counter, interval string, opts CounterOptions) ([]SeriesBucket, error) {
+	groups, err := q.groupByInterval(counter, interval, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return fillSeries(counter, interval, opts, groups)
+}
+
+// GetCountersSeries computes the series of the counters with a single
+// aggregation, the results are in the order of the counters.
+func (q *DataQueries) GetCountersSeries(counters []models.Counter, interval string, opts CounterOptions) ([][]SeriesBucket, error) {
+	byCounter, err := q.groupCountersByInterval(counters, interval, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	series := make([][]SeriesBucket, len(counters))
+	for i, counter := range counters {
+		if series[i], err = fillSeries(counter, interval, opts, byCounter[counter.ID]); err != nil {
+			return nil, err
+		}
+	}
+
+	return series, nil
+}
+
+// fillSeries returns one bucket per interval of the range, the intervals
+// without group are empty.
+func fillSeries(counter models.Counter, interval string, opts CounterOptions, groups []seriesGroup) ([]SeriesBucket, error) {
+	loc := opts.location()
+
+	start := opts.start(counter)
+	if start.IsZero() {
+		if len(groups) == 0 {
+			return []SeriesBucket{}, nil
+		}
+		start = groups[0].Start.In(loc)
+	}
+	end := opts.end(time.Now())
+
+	byStart := make(map[int64]seriesGroup, len(groups))
+	for _, group := range groups {
+		byStart[group.Start.Unix()] = group
+	}
+
+	series := []SeriesBucket{}
+	for t := utils.TruncateInterval(start, interval); t.Before(end); t = utils.AddInterval(t, interval, 1) {
+		if len(series) == maxSeriesBuckets {
+			return nil, ErrTooManyBuckets
+		}
+
+		bucket := SeriesBucket{Date: utils.FormatInterval(t, interval)}
+		if group, ok := byStart[t.Unix()]; ok {
+			bucket.Sum = group.Sum
+			bucket.Count = group.Count
+			bucket.Min = group.Min
+			bucket.Max = group.Max
+			bucket.Avg = float64(group.Sum) / float64(group.Count)
+		}
+		series = append(series, bucket)
+	}
+
+	return series, nil
+}
+
+type CalendarDay struct {
+	Date  string `json:"date"`
+	Total int    `json:"total"`
+	Level int    `json:"level"`
+}
+
+type Calendar struct {
+	From       string        `json:"from"`
+	To         string        `json:"to"`
+	Max        int           `json:"max"`
+	Thresholds []float64     `json:"thresholds"`
+	Days       []CalendarDay `json:"days"`
+}
+
+// GetCounterCalendar returns one cell per day between opts.From and opts.To,
+// which must be set. Days before the soft reset are empty unless global.
+// The level of a day is 0 when empty, otherwise 1 plus the number of
+// quantiles of the non empty days it exceeds.
+func (q *DataQueries) GetCounterCalendar(counter models.Counter, quantiles []float64, opts CounterOptions) (Calendar, error) {
+	loc := opts.location()
+	from := utils.StartOfDay(opts.From.In(loc))
+	to := opts.To.In(loc)
+
+	calendar := Calendar{
+		From:       from.Format(time.DateOnly),
+		To:         to.Add(-time.Nanosecond).Format(time.DateOnly),
+		Thresholds: []float64{},
+		Days:       []CalendarDay{},
+	}
+
+	groups, err := q.groupByInterval(counter, "day", opts)
+	if err != nil {
+		return calendar, err
+	}
+
+	totals := make(map[int64]int, len(groups))
+	var values []float64
+	for _, group := range groups {
+		totals[group.Start.Unix()] = group.Sum
+		if group.Sum > 0 {
+			values = append(values, float64(group.Sum))
+		}
+	}
+	slices.Sort(values)
+
+	if len(values) != 0 {
+		for _, quantile := range quantiles {
+			calendar.Thresholds = append(calendar.Thresholds, utils.Quantile(values, quantile))
+		}
+	}
+
+	for t := from; t.Before(to); t = t.AddDate(0, 0, 1) {
+		day := CalendarDay{Date: t.Format(time.DateOnly), Total: totals[t.Unix()]}
+		if day.Total > 0 {
+			day.Level = 1
+			for _, threshold := range calendar.Thresholds {
+				if float64(day.Total) > threshold {
+					day.Level++
+				}
+			}
+		}
+		calendar.Max = max(calendar.Max, day.Total)
+		calendar.Days = append(calendar.Days, day)
+	}
+
+	return calendar, nil
+}
+
+type Distribution struct {
+	PerDay     utils.Summary `json:"perDay"`
+	PerEntry   utils.Summary `json:"perEntry"`
+	BusiestDay *SeriesBucket `json:"busiestDay"`
+}
+
+// GetCounterDistribution describes the entries and the daily totals of a
+// counter, days without entries count as zero.
+func (q *DataQueries) GetCounterDistribution(counter models.Counter, opts CounterOptions) (Distribution, error) {
+	var distribution Distribution
+	var data []models.Data
+
+	filters := bson.M{
+		"counter_ref": counter.ID,
+		"createdAt":   opts.createdAtFilter(counter),
+	}
+
+	findOpts := options.Find().SetProjection(bson.M{"number": 1})
+	cursor, err := q.Collection.Find(context.TODO(), filters, findOpts)
+	if err != nil {
+		return distribution, err
+	}
+	if err = cursor.All(context.TODO(), &data); err != nil {
+		return distribution, err
+	}
+
+	entries := make([]float64, len(data))
+	for i, entry := range data {
+		entries[i] = float64(entry.Number)
+	}
+	distribution.PerEntry = utils.Summarize(entries)
+
+	series, err := q.GetCounterSeries(counter, "day", opts)
+	if err != nil {
+		return distribution, err
+	}
+
+	days := make([]float64, len(series))
+	for i, bucket := range series {
+		days[i] = float64(bucket.Sum)
+		if bucket.Count > 0 && (distribution.BusiestDay == nil || bucket.Sum > distribution.BusiestDay.Sum) {
+			distribution.BusiestDay = &series[i]
+		}
+	}
+	distribution.PerDay = utils.Summarize(days)
+
+	return distribution, nil
+}
+
+const trendStableThreshold = 0.05
+
+type Trend struct {
+	Days       int      `json:"days"`
+	MA7        *float64 `json:"ma7"`
+	MA30       *float64 `json:"ma30"`
+	MA90       *float64 `json:"ma90"`
+	Window     int      `json:"window"`
+	Slope      float64  `json:"slope"`
+	R2         float64  `json:"r2"`
+	Direction  string   `json:"direction"`
+	Confidence float64  `json:"confidence"`
+	// ChangePct compares the last 30 days with the 30 days before them.
+	ChangePct *float64 `json:"changePct"`
+}
+
+func movingAverage(values []float64, window int) *float64 {
+	if len(values) < window {
+		return nil
+	}
+
+	avg := utils.Mean(values[len(values)-window:])
+	return &avg
+}
+
+// GetCounterTrend analyses the daily totals of a counter, the slope is fitted
+// on the last window days and is expressed in units per day.
+func (q *DataQueries) GetCounterTrend(counter models.Counter, window int, opts CounterOptions) (Trend, error) {
+	trend := Trend{Direction: "stable"}
+
+	series, err := q.GetCounterSeries(counter, "day", opts)
+	if err != nil {
+		return trend, err
+	}
+
+	days := make([]float64, len(series))
+	for i, bucket := range series {
+		days[i] = float64(bucket.Sum)
+	}
+	trend.Days = len(days)
+
+	trend.MA7 = movingAverage(days, 7)
+	trend.MA30 = movingAverage(days, 30)
+	trend.MA90 = movingAverage(days, 90)
+
+	if len(days) >= 60 {
+		last := utils.Mean(days[len(days)-30:])
+		previous := utils.Mean(days[len(days)-60 : len(days)-30])
+		if previous != 0 {
+			change := (last - previous) / math.Abs(previous) * 100
+			trend.ChangePct = &change
+		}
+	}
+
+	trend.Window = min(window, len(days))
+	if trend.Window < 2 {
+		return trend, nil
+	}
+
+	values := days[len(days)-trend.Window:]
+	trend.Slope, _, trend.R2 = utils.LinearRegression(values)
+
+	// relative change predicted by the fit across the whole window
+	mean := utils.Mean(values)
+	relative := 0.0
+	if mean != 0 {
+		relative = trend.Slope * float64(trend.Window-1) / math.Abs(mean)
+	}
+
+	switch {
+	case relative >= trendStableThreshold:
+		trend.Direction = "rising"
+		trend.Confidence = trend.R2
+	case relative <= -trendStableThreshold:
+		trend.Direction = "falling"
+		trend.Confidence = trend.R2
+	default:
+		trend.Confidence = 1 - trend.R2
+	}
+
+	return trend, nil
+}
@@ -0,0 +1,114 @@
+package queries
+
+import (
+	"context"
+	"errors"
+	"main/app/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TriggerQueries struct {
+	Collection *mongo.Collection
+}
+
+var ErrTriggerCooldown = errors.New("the trigger was used too recently")
+
+func (q *TriggerQueries) EnsureIndexes() error {
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "tokenHash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	_, err := q.Collection.Indexes().CreateOne(context.TODO(), index)
+
+	return err
+}
+
+func (q *TriggerQueries) CreateTrigger(newTrigger models.Trigger) (models.Trigger, error) {
+	var trigger models.Trigger
+
+	result, err := q.Collection.InsertOne(context.TODO(), newTrigger)
+	if err != nil {
+		return trigger, err
+	}
+
+	filters := bson.D{{Key: "_id", Value: result.InsertedID}}
+	err = q.Collection.FindOne(context.TODO(), filters).Decode(&trigger)
+	if err != nil {
+		return trigger, err
+	}
+
+	return trigger, nil
+}
+
+func (q *TriggerQueries) GetTriggers(counter models.Counter) ([]models.Trigger, error) {
+	var triggers []models.Trigger
+
+	filters := bson.M{"counter_ref": counter.ID}
+	cursor, err := q.Collection.Find(context.TODO(), filters, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return triggers, err
+	}
+	if err = cursor.All(context.TODO(), &triggers); err != nil {
+		return triggers, err
+	}
+
+	return triggers, nil
+}
+
+// DeleteTrigger revokes a trigger of counter.
+func (q *TriggerQueries) DeleteTrigger(counter models.Counter, triggerID string) (bool, error) {
+	id, err := objectID(triggerID)
+	if err != nil {
+		return false, err
+	}
+
+	filters := bson.M{"_id": id, "counter_ref": counter.ID}
+	res, err := q.Collection.DeleteOne(context.TODO(), filters)
+	if err != nil {
+		return false, err
+	}
+
+	return res.DeletedCount == 1, nil
+}
+
+// GetTrigger returns the trigger of the token hash.
+func (q *TriggerQueries) GetTrigger(tokenHash string) (models.Trigger, error) {
+	var trigger models.Trigger
+
+	err := q.Collection.FindOne(context.TODO(), bson.M{"tokenHash": tokenHash}).Decode(&trigger)
+
+	return trigger, err
+}
+
+// UseTrigger marks the trigger of the token hash as used unless it was used
+// within the cooldown, then it returns the trigger with ErrTriggerCooldown.
+func (q *TriggerQueries) UseTrigger(tokenHash string, cooldown time.Duration) (models.Trigger, error) {
+	var trigger models.Trigger
+
+	now := time.Now()
+	filters := bson.M{
+		"tokenHash": tokenHash,
+		"$or": bson.A{
+			bson.M{"lastUsedAt": bson.M{"$exists": false}},
+			bson.M{"lastUsedAt": bson.M{"$lte": primitive.NewDateTimeFromTime(now.Add(-cooldown))}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"lastUsedAt": primitive.NewDateTimeFromTime(now)}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := q.Collection.FindOneAndUpdate(context.TODO(), filters, update, opts).Decode(&trigger)
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return trigger, err
+	}
+
+	trigger, err = q.GetTrigger(tokenHash)
+	if err != nil {
+		return trigger, err
+	}
+
+	return trigger, ErrTriggerCooldown
+}
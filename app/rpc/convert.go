@@ -0,0 +1,145 @@
+package rpc
+
+import (
+	"fmt"
+	"main/app/models"
+	"main/app/pkg/apierror"
+	"main/app/pkg/utils"
+	"main/app/queries"
+	"main/app/rpc/counterv1"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func timestamp(date primitive.DateTime) *timestamppb.Timestamp {
+	return timestamppb.New(date.Time())
+}
+
+func optionalTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+
+	t := ts.AsTime()
+	return &t
+}
+
+func optionalInt64(value *int) *int64 {
+	if value == nil {
+		return nil
+	}
+
+	n := int64(*value)
+	return &n
+}
+
+func optionalInt(value *int64) *int {
+	if value == nil {
+		return nil
+	}
+
+	n := int(*value)
+	return &n
+}
+
+func toCounter(counter models.Counter) *counterv1.Counter {
+	pb := &counterv1.Counter{
+		Id:        counter.ID.Hex(),
+		Name:      counter.Name,
+		Slug:      counter.Slug,
+		Timezone:  counter.Timezone,
+		Color:     counter.Color,
+		Min:       optionalInt64(counter.Min),
+		Max:       optionalInt64(counter.Max),
+		CreatedAt: timestamp(counter.CreatedAt),
+		UpdatedAt: timestamp(counter.UpdatedAt),
+		Etag:      counter.ETag(),
+	}
+	if counter.SoftReset != nil {
+		pb.SoftReset = timestamp(*counter.SoftReset)
+	}
+
+	return pb
+}
+
+func toData(data models.Data) *counterv1.Data {
+	return &counterv1.Data{
+		Id:        data.ID.Hex(),
+		Number:    int64(data.Number),
+		CounterId: data.Counter.Hex(),
+		Tags:      data.Tags,
+		CreatedAt: timestamp(data.CreatedAt),
+		UpdatedAt: timestamp(data.UpdatedAt),
+	}
+}
+
+func toDatas(datas []models.Data) *counterv1.ListDataResponse {
+	res := &counterv1.ListDataResponse{Data: make([]*counterv1.Data, len(datas))}
+	for i, data := range datas {
+		res.Data[i] = toData(data)
+	}
+
+	return res
+}
+
+func toStats(stats queries.CounterStats) *counterv1.CounterStats {
+	return &counterv1.CounterStats{
+		Version:   int32(stats.Version),
+		CounterId: stats.CounterID.Hex(),
+		Total:     int64(stats.Total),
+		Avg:       stats.Avg,
+		Days:      int64(stats.Days),
+	}
+}
+
+func objectIDs(field string, hexes []string) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, 0, len(hexes))
+	for _, hex := range hexes {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			return nil, apierror.InvalidParameter(fmt.Sprintf("'%s': invalid id '%s'", field, hex))
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// counterOptions reads the options like the query parameters of the v1
+// routes.
+func counterOptions(counter models.Counter, options *counterv1.CounterOptions) (queries.CounterOptions, error) {
+	opts := queries.CounterOptions{Global: options.GetGlobal()}
+
+	name := options.GetTz()
+	if name == "" {
+		name = counter.Timezone
+	}
+	loc, err := utils.LoadLocation(name)
+	if err != nil {
+		return opts, apierror.InvalidParameter(fmt.Sprintf("'tz': invalid timezone '%s'", name))
+	}
+	opts.Location = loc
+
+	if preset := strings.TrimSpace(options.GetRange()); preset != "" {
+		from, to, ok := utils.DateRangePreset(preset, time.Now().In(loc))
+		if !ok {
+			return opts, apierror.InvalidParameter(fmt.Sprintf("'range': invalid preset '%s', expected one of %s", preset, strings.Join(utils.DateRangePresets, ", ")))
+		}
+		opts.From, opts.To = &from, &to
+	}
+	if from := optionalTime(options.GetFrom()); from != nil {
+		opts.From = from
+	}
+	if to := optionalTime(options.GetTo()); to != nil {
+		opts.To = to
+	}
+
+	if opts.From != nil && opts.To != nil && !opts.From.Before(*opts.To) {
+		return opts, apierror.InvalidParameter("'from' must be before 'to'")
+	}
+
+	return opts, nil
+}
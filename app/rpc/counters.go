@@ -0,0 +1,297 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"main/app/models"
+	"main/app/pkg/apierror"
+	. "main/app/pkg/configs"
+	"main/app/pkg/db"
+	"main/app/pkg/events"
+	"main/app/pkg/utils"
+	"main/app/pkg/validation"
+	"main/app/rpc/counterv1"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type counterService struct {
+	counterv1.UnimplementedCounterServiceServer
+}
+
+// checkIfMatch is the If-Match check of the HTTP API.
+func checkIfMatch(ifMatch string, counter models.Counter) error {
+	if ifMatch == "" {
+		if Configs.Bool("concurrency.requireIfMatch") {
+			return apierror.PreconditionRequired("'if_match' is required")
+		}
+
+		return nil
+	}
+	if ifMatch == "*" || ifMatch == counter.ETag() {
+		return nil
+	}
+
+	return apierror.PreconditionFailed(counter)
+}
+
+func (s *counterService) ListCounters(ctx context.Context, req *counterv1.ListCountersRequest) (*counterv1.ListCountersResponse, error) {
+	counters, err := db.Q.GetCounters()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &counterv1.ListCountersResponse{Counters: make([]*counterv1.Counter, len(counters))}
+	for i, counter := range counters {
+		res.Counters[i] = toCounter(counter)
+	}
+
+	return res, nil
+}
+
+func (s *counterService) GetCounter(ctx context.Context, req *counterv1.GetCounterRequest) (*counterv1.Counter, error) {
+	counter, err := db.Q.GetCounter(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return toCounter(counter), nil
+}
+
+func (s *counterService) CreateCounter(ctx context.Context, req *counterv1.CreateCounterRequest) (*counterv1.Counter, error) {
+	pb := req.GetCounter()
+	counter := models.Counter{
+		Name:     pb.GetName(),
+		Slug:     pb.GetSlug(),
+		Timezone: pb.GetTimezone(),
+		Color:    pb.GetColor(),
+		Min:      optionalInt(pb.Min),
+		Max:      optionalInt(pb.Max),
+	}
+	if pb.GetSoftReset() != nil {
+		softReset := primitive.NewDateTimeFromTime(pb.GetSoftReset().AsTime())
+		counter.SoftReset = &softReset
+	}
+
+	if fields := validation.Struct(counter); fields != nil {
+		return nil, apierror.Validation(fields)
+	}
+
+	counter.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+	counter.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+
+	dbdata, err := db.Q.CreateCounter(counter)
+	if err != nil {
+		return nil, err
+	}
+
+	return toCounter(dbdata), nil
+}
+
+func (s *counterService) UpdateCounter(ctx context.Context, req *counterv1.UpdateCounterRequest) (*counterv1.Counter, error) {
+	counter, err := db.Q.GetCounter(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIfMatch(req.GetIfMatch(), counter); err != nil {
+		return nil, err
+	}
+	previous := counter.UpdatedAt
+
+	pb := req.GetCounter()
+	for _, path := range req.GetUpdateMask().GetPaths() {
+		switch path {
+		case "name":
+			counter.Name = pb.GetName()
+		case "slug":
+			counter.Slug = pb.GetSlug()
+		case "soft_reset":
+			counter.SoftReset = nil
+			if pb.GetSoftReset() != nil {
+				softReset := primitive.NewDateTimeFromTime(pb.GetSoftReset().AsTime())
+				counter.SoftReset = &softReset
+			}
+		case "timezone":
+			counter.Timezone = pb.GetTimezone()
+		case "color":
+			counter.Color = pb.GetColor()
+		case "min":
+			counter.Min = optionalInt(pb.Min)
+		case "max":
+			counter.Max = optionalInt(pb.Max)
+		default:
+			return nil, apierror.InvalidParameter(fmt.Sprintf("'update_mask': invalid path '%s'", path))
+		}
+	}
+
+	if fields := validation.Struct(counter); fields != nil {
+		return nil, apierror.Validation(fields)
+	}
+
+	counter.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+	ok, err := db.Q.EditCounter(&counter, previous)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, concurrentCounterChange(counter.ID.Hex())
+	}
+
+	return toCounter(counter), nil
+}
+
+func (s *counterService) DeleteCounter(ctx context.Context, req *counterv1.DeleteCounterRequest) (*counterv1.DeleteCounterResponse, error) {
+	counter, err := db.Q.GetCounter(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if err := checkIfMatch(req.GetIfMatch(), counter); err != nil {
+		return nil, err
+	}
+
+	ok, err := db.Q.DeleteCounter(counter)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, concurrentCounterChange(counter.ID.Hex())
+	}
+
+	return &counterv1.DeleteCounterResponse{}, nil
+}
+
+func concurrentCounterChange(id string) error {
+	current, err := db.Q.GetCounter(id)
+	if err != nil {
+		return err
+	}
+
+	return apierror.PreconditionFailed(current)
+}
+
+func (s *counterService) GetCounterData(ctx context.Context, req *counterv1.GetCounterDataRequest) (*counterv1.ListDataResponse, error) {
+	counter, err := db.Q.GetCounter(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := counterOptions(counter, req.GetOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	datas, err := db.Q.GetCounterData(counter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return toDatas(datas), nil
+}
+
+func (s *counterService) GetCounterStats(ctx context.Context, req *counterv1.GetCounterStatsRequest) (*counterv1.CounterStats, error) {
+	counter, err := db.Q.GetCounter(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := counterOptions(counter, req.GetOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := db.Q.GetCounterStats(counter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return toStats(stats), nil
+}
+
+func (s *counterService) GetCounterSeries(ctx context.Context, req *counterv1.GetCounterSeriesRequest) (*counterv1.GetCounterSeriesResponse, error) {
+	counter, err := db.Q.GetCounter(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := counterOptions(counter, req.GetOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	interval := req.GetInterval()
+	if interval == "" {
+		interval = "day"
+	}
+	if !slices.Contains(utils.Intervals, interval) {
+		return nil, apierror.InvalidParameter(fmt.Sprintf("'interval': invalid interval '%s', expected one of %s", interval, strings.Join(utils.Intervals, ", ")))
+	}
+
+	series, err := db.Q.GetCounterSeries(counter, interval, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &counterv1.GetCounterSeriesResponse{Buckets: make([]*counterv1.SeriesBucket, len(series))}
+	for i, bucket := range series {
+		res.Buckets[i] = &counterv1.SeriesBucket{
+			Date:  bucket.Date,
+			Sum:   int64(bucket.Sum),
+			Count: int64(bucket.Count),
+			Avg:   bucket.Avg,
+			Min:   int64(bucket.Min),
+			Max:   int64(bucket.Max),
+		}
+	}
+
+	return res, nil
+}
+
+func (s *counterService) WatchCounter(req *counterv1.WatchCounterRequest, stream counterv1.CounterService_WatchCounterServer) error {
+	counter, err := db.Q.GetCounter(req.GetId())
+	if err != nil {
+		return err
+	}
+
+	opts, err := counterOptions(counter, req.GetOptions())
+	if err != nil {
+		return err
+	}
+
+	// subscribed first so that no entry is missed between the two
+	entries, cancel := events.Entries.Subscribe([]primitive.ObjectID{counter.ID})
+	defer cancel()
+
+	send := func(event *events.Event) error {
+		stats, err := db.Q.GetCounterStats(counter, opts)
+		if err != nil {
+			return err
+		}
+
+		res := &counterv1.WatchCounterResponse{Stats: toStats(stats)}
+		if event != nil && event.Deleted {
+			res.Removed = toData(event.Entry)
+		} else if event != nil {
+			res.Entry = toData(event.Entry)
+		}
+
+		return stream.Send(res)
+	}
+
+	if err := send(nil); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event := <-entries:
+			if err := send(&event); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"main/app/models"
+	"main/app/pkg/apierror"
+	"main/app/pkg/db"
+	"main/app/pkg/events"
+	"main/app/pkg/validation"
+	"main/app/queries"
+	"main/app/rpc/counterv1"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type dataService struct {
+	counterv1.UnimplementedDataServiceServer
+}
+
+func (s *dataService) CreateData(ctx context.Context, req *counterv1.CreateDataRequest) (*counterv1.Data, error) {
+	pb := req.GetData()
+	data := models.Data{Number: int(pb.GetNumber()), Tags: pb.GetTags()}
+
+	if pb.GetCounterId() == "" {
+		return nil, apierror.Validation([]validation.FieldError{{Field: "counterRef", Rule: "required", Msg: "'counterRef' is required"}})
+	}
+	counterID, err := primitive.ObjectIDFromHex(pb.GetCounterId())
+	if err != nil {
+		return nil, apierror.Validation([]validation.FieldError{{Field: "counterRef", Rule: "objectid", Msg: "'counterRef' must be a valid id"}})
+	}
+	data.Counter = counterID
+
+	if fields := validation.Struct(data); fields != nil {
+		return nil, apierror.Validation(fields)
+	}
+
+	counter, err := db.Q.GetCounter(data.Counter.Hex())
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, apierror.Validation([]validation.FieldError{{Field: "counterRef", Rule: "exists", Msg: "'counterRef' must be an existing counter"}})
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fields := validation.DataBounds(data, counter); fields != nil {
+		return nil, apierror.Validation(fields)
+	}
+
+	data.CreatedAt = primitive.NewDateTimeFromTime(time.Now())
+	if pb.GetCreatedAt() != nil {
+		data.CreatedAt = primitive.NewDateTimeFromTime(pb.GetCreatedAt().AsTime())
+	}
+	data.UpdatedAt = primitive.NewDateTimeFromTime(time.Now())
+
+	dbdata, err := db.Q.CreateData(data)
+	if err != nil {
+		return nil, err
+	}
+	events.Entries.Publish(dbdata)
+
+	return toData(dbdata), nil
+}
+
+func (s *dataService) ListData(ctx context.Context, req *counterv1.ListDataRequest) (*counterv1.ListDataResponse, error) {
+	if req.GetLimit() < 0 {
+		return nil, apierror.InvalidParameter("'limit' may not be negative")
+	}
+
+	opts := queries.ListOptions{
+		Limit:     req.GetLimit(),
+		Ordering:  req.GetOrdering(),
+		From:      optionalTime(req.GetFrom()),
+		To:        optionalTime(req.GetTo()),
+		MinNumber: optionalInt(req.Min),
+		MaxNumber: optionalInt(req.Max),
+		Tags:      req.GetTags(),
+	}
+
+	counters, err := objectIDs("counter_ids", req.GetCounterIds())
+	if err != nil {
+		return nil, err
+	}
+	opts.Counters = counters
+
+	datas, err := db.Q.GetDatas(opts)
+	if errors.Is(err, queries.ErrInvalidOrdering) {
+		return nil, apierror.InvalidParameter(fmt.Sprintf("%s '%s'", err.Error(), opts.Ordering))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toDatas(datas), nil
+}
+
+func (s *dataService) GetData(ctx context.Context, req *counterv1.GetDataRequest) (*counterv1.Data, error) {
+	data, err := db.Q.GetData(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return toData(data), nil
+}
+
+func (s *dataService) DeleteData(ctx context.Context, req *counterv1.DeleteDataRequest) (*counterv1.DeleteDataResponse, error) {
+	deleted, err := db.Q.DeleteData(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if !deleted {
+		return nil, apierror.NotFound("data not found")
+	}
+
+	return &counterv1.DeleteDataResponse{}, nil
+}
@@ -0,0 +1,99 @@
+package rpc
+
+import (
+	"context"
+	"log"
+	"main/app/pkg/apierror"
+	"main/app/rpc/counterv1"
+	"net"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//go:generate protoc -I ../../proto --go_out=. --go_opt=module=main/app/rpc --go-grpc_out=. --go-grpc_opt=module=main/app/rpc counter/v1/counter.proto
+
+var codesByStatus = map[int]codes.Code{
+	fiber.StatusBadRequest:           codes.InvalidArgument,
+	fiber.StatusNotFound:             codes.NotFound,
+	fiber.StatusConflict:             codes.AlreadyExists,
+	fiber.StatusPreconditionFailed:   codes.FailedPrecondition,
+	fiber.StatusUnprocessableEntity:  codes.InvalidArgument,
+	fiber.StatusPreconditionRequired: codes.FailedPrecondition,
+	fiber.StatusServiceUnavailable:   codes.Unavailable,
+}
+
+// toStatus maps the errors of the services like apierror.Handler does for
+// the HTTP API, the invalid fields are BadRequest details.
+func toStatus(method string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	apiError := apierror.From(err)
+	if apiError.Status >= fiber.StatusInternalServerError {
+		log.Printf("%s: %v", method, err)
+	}
+
+	code, ok := codesByStatus[apiError.Status]
+	if !ok {
+		code = codes.Internal
+	}
+
+	st := status.New(code, apiError.Msg)
+	if len(apiError.Fields) != 0 {
+		details := &errdetails.BadRequest{}
+		for _, field := range apiError.Fields {
+			details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       field.Field,
+				Description: field.Msg,
+			})
+		}
+		if withDetails, err := st.WithDetails(details); err == nil {
+			st = withDetails
+		}
+	}
+
+	return st.Err()
+}
+
+func unaryErrors(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	res, err := handler(ctx, req)
+	if err != nil {
+		return nil, toStatus(info.FullMethod, err)
+	}
+
+	return res, nil
+}
+
+func streamErrors(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := handler(srv, ss); err != nil {
+		return toStatus(info.FullMethod, err)
+	}
+
+	return nil
+}
+
+func NewServer() *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryErrors),
+		grpc.ChainStreamInterceptor(streamErrors),
+	)
+	counterv1.RegisterCounterServiceServer(server, &counterService{})
+	counterv1.RegisterDataServiceServer(server, &dataService{})
+
+	return server
+}
+
+// Serve listens on address until the server is stopped.
+func Serve(server *grpc.Server, address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	return server.Serve(listener)
+}
@@ -3,8 +3,11 @@ package main
 import (
 	"log"
 	"main/app/api"
+	"main/app/pkg/apierror"
 	. "main/app/pkg/configs"
 	"main/app/pkg/db"
+	"main/app/rpc"
+	_ "time/tzdata"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/logger"
@@ -18,7 +21,8 @@ func main() {
 
 	proxyHeader := Configs.String("general.proxyHeader")
 	app := fiber.New(fiber.Config{
-		ProxyHeader: proxyHeader,
+		ProxyHeader:  proxyHeader,
+		ErrorHandler: apierror.Handler,
 	})
 	app.Use(logger.New())
 
@@ -37,6 +41,18 @@ func main() {
 		})
 	}
 
+	// the gRPC services are disabled without a port
+	if GRPC_PORT := Configs.String("grpc.port"); GRPC_PORT != "" {
+		server := rpc.NewServer()
+		defer server.GracefulStop()
+
+		go func() {
+			if err := rpc.Serve(server, GRPC_PORT); err != nil {
+				log.Panicf("Oops... gRPC server is not running! Reason: %v", err)
+			}
+		}()
+	}
+
 	PORT := Configs.String("general.port")
 	if PORT == "" {
 		PORT = ":8080"